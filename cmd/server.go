@@ -2,25 +2,47 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	GoogleGRPC "google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
 	pb "github.com/ShopOnGO/product-proto/pkg/product"
 	"github.com/ShopOnGO/product-service/configs"
+	"github.com/ShopOnGO/product-service/internal/attribute"
+	"github.com/ShopOnGO/product-service/internal/audit"
+	"github.com/ShopOnGO/product-service/internal/auth"
 	"github.com/ShopOnGO/product-service/internal/brand"
+	"github.com/ShopOnGO/product-service/internal/campaign"
 	"github.com/ShopOnGO/product-service/internal/category"
+	"github.com/ShopOnGO/product-service/internal/deadletter"
 	"github.com/ShopOnGO/product-service/internal/grpc"
+	"github.com/ShopOnGO/product-service/internal/grpcserver"
+	"github.com/ShopOnGO/product-service/internal/health"
+	"github.com/ShopOnGO/product-service/internal/inbox"
+	"github.com/ShopOnGO/product-service/internal/metrics"
+	"github.com/ShopOnGO/product-service/internal/outbox"
 	"github.com/ShopOnGO/product-service/internal/product"
 	"github.com/ShopOnGO/product-service/internal/productVariant"
+	"github.com/ShopOnGO/product-service/internal/tracing"
 	"github.com/ShopOnGO/product-service/migrations"
 	"github.com/ShopOnGO/product-service/pkg/db"
 	"github.com/gin-contrib/cors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	"github.com/gin-gonic/gin"
 
@@ -35,6 +57,9 @@ import (
 // @host localhost:8082
 // @BasePath /
 // @schemes http
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
 func main() {
 	migrations.CheckForMigrations()
 	conf := configs.LoadConfig()
@@ -43,23 +68,25 @@ func main() {
 	logger.InitLogger(consoleLvl, fileLvl)
 	logger.EnableFileLogging("TailorNado_product-service")
 
-	database := db.NewDB(conf)
-	kafkaProducers := kafkaService.InitKafkaProducers(
-		conf.KafkaProducer.Brokers,
-		conf.KafkaProducer.Topic,
-	)
-	for k, v := range kafkaProducers {
-		logger.Infof("Kafka key: %s, producer: %v", k, v)
-	}
-	for k, v := range kafkaProducers {
-		if v == nil {
-			logger.Infof("Kafka producer for key %s is nil!", k)
-		} else {
-			logger.Infof("Kafka producer for key %s initialized successfully", k)
-		}
+	shutdownTracing, err := tracing.Init(conf.Tracing)
+	if err != nil {
+		logger.Errorf("Invalid tracing configuration: %v", err)
+		os.Exit(1)
 	}
 
+	database, err := db.NewDB(conf)
+	if err != nil {
+		logger.Errorf("Invalid database configuration: %v", err)
+		os.Exit(1)
+	}
+	// InitKafkaProducers не используется: он ставит свой обработчик SIGTERM с os.Exit,
+	// который оборвал бы graceful shutdown ниже.
+	kafkaProducers := newKafkaProducers(conf.KafkaProducer)
+
 	router := gin.Default()
+	router.Use(otelgin.Middleware(conf.Tracing.ServiceName))
+	router.Use(metrics.GinMiddleware())
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	router.Use(cors.New(cors.Config{
 		// Разрешаем запросы с фронтенда
@@ -74,35 +101,91 @@ func main() {
 	}))
 
 	// repository
-	productRepo := product.NewProductRepository(database)
+	productVariantRepo := productVariant.NewProductVariantRepository(database)
+	productRepo := product.NewProductRepository(database, productVariantRepo)
 	brandRepo := brand.NewBrandRepository(database)
 	categoryRepo := category.NewCategoryRepository(database)
-	productVariantRepo := productVariant.NewProductVariantRepository(database)
+	reservationRepo := productVariant.NewReservationRepository(database)
+	outboxRepo := outbox.NewOutboxRepository(database)
+	inboxRepo := inbox.NewInboxRepository(database)
+	deadLetterRepo := deadletter.NewDeadLetterRepository(database)
+	auditRepo := audit.NewAuditRepository(database)
+	attributeRepo := attribute.NewAttributeRepository(database)
+	campaignRepo := campaign.NewCampaignRepository(database)
 
 	// service
-	productService := product.NewProductService(productRepo)
-	brandService := brand.NewBrandService(brandRepo)
-	categoryService := category.NewCategoryService(categoryRepo)
-	productVariantService := productVariant.NewProductVariantService(productVariantRepo)
+	attributeService := attribute.NewAttributeService(attributeRepo)
+	productService := product.NewProductService(productRepo, attributeService)
+	brandService := brand.NewBrandService(brandRepo, productRepo)
+	categoryService := category.NewCategoryService(categoryRepo, productRepo, productVariantRepo)
+	productVariantService := productVariant.NewProductVariantService(productVariantRepo, attributeService)
+	reservationService := productVariant.NewReservationService(reservationRepo, conf.Reservation.TTL)
+	deadLetterService := deadletter.NewDeadLetterService(deadLetterRepo)
+	auditService := audit.NewAuditService(auditRepo)
+	campaignService := campaign.NewCampaignService(campaignRepo, productVariantRepo)
 
 	// handler
+	if conf.Auth.Secret == "" {
+		logger.Warnf("SECRET is not set, all authenticated endpoints will respond 401")
+	}
+	authMiddleware := auth.Middleware(conf.Auth.Secret)
 	product.NewProductHandler(router, product.ProductHandlerDeps{
 		ProductSvc: productService,
 		Kafka:      kafkaProducers["products"],
+		Auth:       authMiddleware,
 	})
 	brand.NewBrandHandler(router, brand.BrandHandlerDeps{
 		BrandSvc: brandService,
 		Kafka:    kafkaProducers["brands"],
+		Auth:     authMiddleware,
 	})
 	category.NewCategoryHandler(router, category.CategoryHandlerDeps{
 		CategorySvc: categoryService,
 		Kafka:       kafkaProducers["categories"],
+		Auth:        authMiddleware,
 	})
 	productVariant.NewProductVariantHandler(router, productVariant.ProductVariantHandlerDeps{
 		ProductVariantSvc: productVariantService,
+		ReservationSvc:    reservationService,
 		Kafka:             kafkaProducers["variants"],
+		Auth:              authMiddleware,
+	})
+	attribute.NewAttributeHandler(router, attribute.AttributeHandlerDeps{
+		AttributeSvc: attributeService,
+		Auth:         authMiddleware,
+	})
+	campaign.NewCampaignHandler(router, campaign.CampaignHandlerDeps{
+		CampaignSvc: campaignService,
+		Auth:        authMiddleware,
+	})
+	productVariant.NewReservationHandler(router, productVariant.ReservationHandlerDeps{
+		ReservationSvc: reservationService,
+		Auth:           authMiddleware,
+	})
+	deadletter.NewDeadLetterHandler(router, deadletter.DeadLetterHandlerDeps{
+		DeadLetterSvc: deadLetterService,
+		Auth:          authMiddleware,
+	})
+	audit.NewAuditHandler(router, audit.AuditHandlerDeps{
+		AuditSvc: auditService,
+		Auth:     authMiddleware,
+	})
+	reviewClients, err := grpc.InitGRPCClients()
+	if err != nil {
+		logger.Errorf("Invalid review-service configuration: %v", err)
+		os.Exit(1)
+	}
+	grpc.NewReviewHandler(router, reviewClients)
+
+	healthService := health.NewHealthService(map[string]health.Check{
+		"postgres":        health.PostgresCheck(database),
+		"kafka_producers": health.KafkaCheck(conf.KafkaProducer.Brokers, producerTopics(conf)),
+		"kafka_consumers": health.KafkaCheck(conf.KafkaProduct.Brokers, []string{conf.KafkaProduct.Topic, conf.KafkaVariant.Topic, conf.KafkaMedia.Topic}),
+		"review_service":  health.GRPCCheck(reviewClients.Conn),
+	})
+	health.NewHealthHandler(router, health.HealthHandlerDeps{
+		HealthSvc: healthService,
 	})
-	grpc.NewReviewHandler(router)
 
 	kafkaProductConsumer := kafkaService.NewConsumer(
 		conf.KafkaProduct.Brokers,
@@ -123,25 +206,82 @@ func main() {
 		conf.KafkaMedia.ClientID,
 	)
 
-	defer kafkaProductConsumer.Close()
-	defer kafkaVariantConsumer.Close()
-	defer kafkaMediaConsumer.Close()
-
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	go kafkaProductConsumer.Consume(ctx, func(msg kafka.Message) error {
+
+	productHandler := inbox.Idempotent(inboxRepo, "product", func(ctx context.Context, msg kafka.Message) error {
 		key := string(msg.Key)
-		return product.HandleProductEvent(msg.Value, key, productService, productVariantService, kafkaProducers["products"])
+		return product.HandleProductEvent(ctx, msg.Value, key, productService, productVariantService)
 	})
-
-	go kafkaVariantConsumer.Consume(ctx, func(msg kafka.Message) error {
+	variantHandler := inbox.Idempotent(inboxRepo, "variant", func(ctx context.Context, msg kafka.Message) error {
 		key := string(msg.Key)
-		return productVariant.HandleProductVariantEvent(msg.Value, key, productVariantService)
+		return productVariant.HandleProductVariantEvent(ctx, msg.Value, key, productVariantService)
 	})
-	go kafkaMediaConsumer.Consume(ctx, func(msg kafka.Message) error {
+	mediaHandler := inbox.Idempotent(inboxRepo, "media", func(ctx context.Context, msg kafka.Message) error {
 		key := string(msg.Key)
-		return product.HandleProductEvent(msg.Value, key, productService, productVariantService, nil)
+		return product.HandleProductEvent(ctx, msg.Value, key, productService, productVariantService)
+	})
+
+	dlqProducers := map[string]*kafkaService.KafkaService{
+		"product": newDLQProducer(conf.KafkaProduct),
+		"variant": newDLQProducer(conf.KafkaVariant),
+		"media":   newDLQProducer(conf.KafkaMedia),
+	}
+	deadLetterService.RegisterConsumer("product", dlqProducers["product"], productHandler)
+	deadLetterService.RegisterConsumer("variant", dlqProducers["variant"], variantHandler)
+	deadLetterService.RegisterConsumer("media", dlqProducers["media"], mediaHandler)
+
+	metrics.RegisterConsumerLag(map[string]*kafkaService.KafkaService{
+		"product": kafkaProductConsumer,
+		"variant": kafkaVariantConsumer,
+		"media":   kafkaMediaConsumer,
+	})
+	if sqlDB, err := database.DB.DB(); err == nil {
+		prometheus.MustRegister(collectors.NewDBStatsCollector(sqlDB, "product_service"))
+	}
+
+	retryPolicy := deadletter.RetryPolicy{
+		MaxAttempts:    conf.ConsumerRetry.MaxAttempts,
+		InitialBackoff: conf.ConsumerRetry.InitialBackoff,
+		MaxBackoff:     conf.ConsumerRetry.MaxBackoff,
+	}
+
+	// Consumers и фоновые задачи работают до отмены ctx; workers ждёт их завершения при остановке.
+	var workers sync.WaitGroup
+	runWorker := func(run func()) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run()
+		}()
+	}
+
+	runWorker(func() {
+		kafkaProductConsumer.Consume(ctx, tracing.KafkaConsumer("product", deadletter.WithRetry(ctx, deadLetterService, "product", retryPolicy, metrics.InstrumentConsumer(productHandler))))
 	})
+	runWorker(func() {
+		kafkaVariantConsumer.Consume(ctx, tracing.KafkaConsumer("variant", deadletter.WithRetry(ctx, deadLetterService, "variant", retryPolicy, metrics.InstrumentConsumer(variantHandler))))
+	})
+	runWorker(func() {
+		kafkaMediaConsumer.Consume(ctx, tracing.KafkaConsumer("media", deadletter.WithRetry(ctx, deadLetterService, "media", retryPolicy, metrics.InstrumentConsumer(mediaHandler))))
+	})
+	runWorker(func() {
+		productVariant.NewReservationSweeper(reservationService, conf.Reservation.SweepInterval).Run(ctx)
+	})
+	runWorker(func() {
+		campaign.NewCampaignScheduler(campaignService, conf.Campaign.SyncInterval).Run(ctx)
+	})
+	runWorker(func() {
+		healthService.Watch(ctx, conf.HealthCheckInterval)
+	})
+	runWorker(func() {
+		outbox.NewRelay(outboxRepo, kafkaProducers, conf.Outbox.PollInterval, conf.Outbox.BatchSize, conf.Outbox.Lease).Run(ctx)
+	})
+
+	grpcServer := grpcserver.NewServer(conf.GRPC)
+	pb.RegisterProductVariantServiceServer(grpcServer, productVariant.NewGrpcProductVariantService(productVariantService, reservationService))
+	pb.RegisterProductServiceServer(grpcServer, product.NewGrpcProductService(productService))
+	healthpb.RegisterHealthServer(grpcServer, healthService.GRPCServer())
 
 	go func() {
 		listener, err := net.Listen("tcp", ":50053")
@@ -150,21 +290,144 @@ func main() {
 			return
 		}
 
-		grpcServer := GoogleGRPC.NewServer()
-		pb.RegisterProductVariantServiceServer(grpcServer, productVariant.NewGrpcProductVariantService(productVariantService))
-		pb.RegisterProductServiceServer(grpcServer, product.NewGrpcProductService(productService))
-
 		logger.Info("gRPC server listening on :50053")
 		if err := grpcServer.Serve(listener); err != nil {
 			logger.Infof("gRPC server error: %v\n", err)
 		}
 	}()
 
+	httpServer := &http.Server{
+		Addr:    ":8082",
+		Handler: router,
+	}
 	go func() {
-		if err := router.Run(":8082"); err != nil {
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			fmt.Println("Ошибка при запуске HTTP-сервера:", err)
 		}
 	}()
 
-	select {}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	sig := <-stop
+	logger.Infof("Получен сигнал %s, останавливаем сервис (таймаут %s)", sig, conf.ShutdownTimeout)
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), conf.ShutdownTimeout)
+	defer cancelShutdown()
+
+	// 1. Сообщаем балансировщику, что не готовы, перестаём принимать HTTP и gRPC
+	// и дожидаемся запросов в работе.
+	healthService.Shutdown()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Errorf("HTTP server shutdown: %v", err)
+	}
+	stopGRPCServer(shutdownCtx, grpcServer)
+
+	// 2. Останавливаем consumers (текущее сообщение дообрабатывается) и фоновые задачи.
+	cancel()
+	if !waitWithTimeout(shutdownCtx, &workers) {
+		logger.Warnf("Consumers and background workers did not stop before the deadline")
+	}
+	// Close выходит из consumer group, фиксируя прочитанные офсеты.
+	for name, consumer := range map[string]*kafkaService.KafkaService{
+		"product": kafkaProductConsumer,
+		"variant": kafkaVariantConsumer,
+		"media":   kafkaMediaConsumer,
+	} {
+		if err := consumer.Close(); err != nil {
+			logger.Errorf("Kafka consumer %s close: %v", name, err)
+		}
+	}
+
+	// 3. Close у writer'а дожидается отправки буферизованных сообщений.
+	closeProducers(kafkaProducers)
+	closeProducers(dlqProducers)
+	// Спаны последних сообщений и запросов досылаются после закрытия продюсеров.
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Errorf("Tracing shutdown: %v", err)
+	}
+
+	if err := reviewClients.Conn.Close(); err != nil {
+		logger.Errorf("Review-service connection close: %v", err)
+	}
+
+	// 4. Пул соединений с БД закрывается последним: до этого момента им пользуются все остальные.
+	if sqlDB, err := database.DB.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			logger.Errorf("DB close: %v", err)
+		}
+	}
+	logger.Info("Product service stopped")
+}
+
+// newKafkaProducers создаёт продюсер на каждый топик из KAFKA_PRODUCER_TOPIC.
+func newKafkaProducers(conf configs.KafkaProducerConfig) map[string]*kafkaService.KafkaService {
+	producers := make(map[string]*kafkaService.KafkaService, len(conf.Topic))
+	for name, topic := range conf.Topic {
+		producers[name] = kafkaService.NewProducer(conf.Brokers, topic)
+		logger.Infof("Kafka producer for key %s initialized successfully", name)
+	}
+	return producers
+}
+
+// producerTopics — все топики, в которые пишет сервис, включая DLQ.
+func producerTopics(conf *configs.Config) []string {
+	topics := make([]string, 0, len(conf.KafkaProducer.Topic)+3)
+	for _, topic := range conf.KafkaProducer.Topic {
+		topics = append(topics, topic)
+	}
+	for _, consumer := range []configs.KafkaConsumerConfig{conf.KafkaProduct, conf.KafkaVariant, conf.KafkaMedia} {
+		if consumer.DLQTopic != "" {
+			topics = append(topics, consumer.DLQTopic)
+		}
+	}
+	return topics
+}
+
+func closeProducers(producers map[string]*kafkaService.KafkaService) {
+	for name, producer := range producers {
+		if producer == nil {
+			continue
+		}
+		if err := producer.Close(); err != nil {
+			logger.Errorf("Kafka producer %s close: %v", name, err)
+		}
+	}
+}
+
+// stopGRPCServer дожидается завершения текущих RPC, а по истечении ctx обрывает их.
+func stopGRPCServer(ctx context.Context, server *GoogleGRPC.Server) {
+	done := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logger.Warnf("gRPC graceful stop timed out, forcing stop")
+		server.Stop()
+	}
+}
+
+// waitWithTimeout ждёт wg не дольше ctx и сообщает, успели ли все завершиться.
+func waitWithTimeout(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// newDLQProducer создаёт продюсер DLQ-топика consumer'а; без настроенного топика dead letters пишутся только в БД.
+func newDLQProducer(conf configs.KafkaConsumerConfig) *kafkaService.KafkaService {
+	if conf.DLQTopic == "" {
+		return nil
+	}
+	return kafkaService.NewProducer(conf.Brokers, conf.DLQTopic)
 }
@@ -2,7 +2,9 @@ package configs
 
 import (
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ShopOnGO/ShopOnGO/configs"
 	"github.com/joho/godotenv"
@@ -11,17 +13,31 @@ import (
 )
 
 type Config struct {
-	Db            DbConfig
-	KafkaProduct  KafkaConsumerConfig
-	KafkaVariant  KafkaConsumerConfig
-	KafkaMedia    KafkaConsumerConfig
-	KafkaProducer KafkaProducerConfig
-	LogLevel      logger.LogLevel
-	FileLogLevel  logger.LogLevel
+	Db                  DbConfig
+	Auth                AuthConfig
+	KafkaProduct        KafkaConsumerConfig
+	KafkaVariant        KafkaConsumerConfig
+	KafkaMedia          KafkaConsumerConfig
+	KafkaProducer       KafkaProducerConfig
+	Reservation         ReservationConfig
+	Campaign            CampaignConfig
+	Outbox              OutboxConfig
+	ConsumerRetry       ConsumerRetryConfig
+	Tracing             TracingConfig
+	GRPC                GRPCConfig
+	ShutdownTimeout     time.Duration // сколько ждать завершения запросов и consumers при остановке
+	HealthCheckInterval time.Duration // как часто обновлять статус grpc.health.v1
+	LogLevel            logger.LogLevel
+	FileLogLevel        logger.LogLevel
 }
 
 type DbConfig struct {
-	Dsn string
+	Dsn          string
+	QueryTimeout time.Duration // срок SQL-запроса, если у контекста вызова нет своего deadline
+}
+
+type AuthConfig struct {
+	Secret string // ключ подписи JWT, общий с ShopOnGO
 }
 
 type KafkaConsumerConfig struct {
@@ -29,6 +45,7 @@ type KafkaConsumerConfig struct {
 	Topic    string
 	GroupID  string
 	ClientID string
+	DLQTopic string // куда уходят сообщения, не обработанные после всех повторов
 }
 
 type KafkaProducerConfig struct {
@@ -36,6 +53,41 @@ type KafkaProducerConfig struct {
 	Topic   map[string]string
 }
 
+type ReservationConfig struct {
+	TTL           time.Duration // срок брони по умолчанию
+	SweepInterval time.Duration // как часто снимать просроченные брони
+}
+
+type CampaignConfig struct {
+	SyncInterval time.Duration // как часто применять начавшиеся и снимать закончившиеся акции
+}
+
+type OutboxConfig struct {
+	PollInterval time.Duration // как часто relay проверяет неотправленные события
+	BatchSize    int           // сколько событий отправлять за один проход
+	Lease        time.Duration // аренда сообщения relay, продлевается перед каждой публикацией
+}
+
+type ConsumerRetryConfig struct {
+	MaxAttempts    int           // попыток обработки, включая первую
+	InitialBackoff time.Duration // задержка перед первым повтором, дальше удваивается
+	MaxBackoff     time.Duration
+}
+
+type GRPCConfig struct {
+	MaxRecvMsgSize int           // предельный размер входящего сообщения, байт
+	MaxSendMsgSize int           // предельный размер исходящего сообщения, байт
+	DefaultTimeout time.Duration // срок unary-вызова, если клиент не передал deadline
+}
+
+type TracingConfig struct {
+	Exporter     string  // otlp, stdout или none
+	OTLPEndpoint string  // host:port OTLP/gRPC коллектора
+	OTLPInsecure bool    // без TLS до коллектора
+	ServiceName  string  // service.name в ресурсе трейсов
+	SampleRatio  float64 // доля трейсов, начинаемых этим сервисом
+}
+
 func LoadConfig() *Config {
 	if _, err := os.Stat(".env"); err == nil {
 		// Локально есть .env → загружаем
@@ -63,33 +115,131 @@ func LoadConfig() *Config {
 
 	return &Config{
 		Db: DbConfig{
-			Dsn: os.Getenv("DSN"),
+			Dsn:          os.Getenv("DSN"),
+			QueryTimeout: parseDuration("DB_QUERY_TIMEOUT", 30*time.Second),
+		},
+		Auth: AuthConfig{
+			Secret: os.Getenv("SECRET"),
 		},
 		KafkaProduct: KafkaConsumerConfig{
 			Brokers:  brokers,
 			Topic:    os.Getenv("KAFKA_PRODUCT_TOPIC"),
 			GroupID:  os.Getenv("KAFKA_PRODUCT_GROUP_ID"),
 			ClientID: os.Getenv("KAFKA_PRODUCT_CLIENT_ID"),
+			DLQTopic: dlqTopic("KAFKA_PRODUCT_DLQ_TOPIC", os.Getenv("KAFKA_PRODUCT_TOPIC")),
 		},
 		KafkaVariant: KafkaConsumerConfig{
 			Brokers:  brokers,
 			Topic:    os.Getenv("KAFKA_VARIANT_TOPIC"),
 			GroupID:  os.Getenv("KAFKA_VARIANT_GROUP_ID"),
 			ClientID: os.Getenv("KAFKA_VARIANT_CLIENT_ID"),
+			DLQTopic: dlqTopic("KAFKA_VARIANT_DLQ_TOPIC", os.Getenv("KAFKA_VARIANT_TOPIC")),
 		},
 		KafkaMedia: KafkaConsumerConfig{
 			Brokers:  brokers,
 			Topic:    os.Getenv("KAFKA_MEDIA_TOPIC"),
 			GroupID:  os.Getenv("KAFKA_MEDIA_GROUP_ID"),
 			ClientID: os.Getenv("KAFKA_MEDIA_CLIENT_ID"),
+			DLQTopic: dlqTopic("KAFKA_MEDIA_DLQ_TOPIC", os.Getenv("KAFKA_MEDIA_TOPIC")),
 		},
 		KafkaProducer: KafkaProducerConfig{
 			Brokers: brokers,
 			Topic:   parseKafkaTopics(os.Getenv("KAFKA_PRODUCER_TOPIC")),
 		},
-		LogLevel:     LogLevel,
-		FileLogLevel: FileLogLevel,
+		Reservation: ReservationConfig{
+			TTL:           parseDuration("RESERVATION_TTL", 15*time.Minute),
+			SweepInterval: parseDuration("RESERVATION_SWEEP_INTERVAL", time.Minute),
+		},
+		Campaign: CampaignConfig{
+			SyncInterval: parseDuration("CAMPAIGN_SYNC_INTERVAL", time.Minute),
+		},
+		Outbox: OutboxConfig{
+			PollInterval: parseDuration("OUTBOX_POLL_INTERVAL", time.Second),
+			BatchSize:    parseInt("OUTBOX_BATCH_SIZE", 100),
+			Lease:        parseDuration("OUTBOX_LEASE", 30*time.Second),
+		},
+		ConsumerRetry: ConsumerRetryConfig{
+			MaxAttempts:    parseInt("KAFKA_CONSUMER_MAX_ATTEMPTS", 5),
+			InitialBackoff: parseDuration("KAFKA_CONSUMER_RETRY_BACKOFF", 500*time.Millisecond),
+			MaxBackoff:     parseDuration("KAFKA_CONSUMER_RETRY_MAX_BACKOFF", 30*time.Second),
+		},
+		Tracing: TracingConfig{
+			Exporter:     getEnv("OTEL_TRACES_EXPORTER", "none"),
+			OTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317"),
+			OTLPInsecure: os.Getenv("OTEL_EXPORTER_OTLP_INSECURE") != "false",
+			ServiceName:  getEnv("OTEL_SERVICE_NAME", "product-service"),
+			SampleRatio:  parseRatio("OTEL_TRACES_SAMPLER_RATIO", 1),
+		},
+		GRPC: GRPCConfig{
+			MaxRecvMsgSize: parseInt("GRPC_MAX_RECV_MSG_SIZE", 4<<20),
+			MaxSendMsgSize: parseInt("GRPC_MAX_SEND_MSG_SIZE", 16<<20),
+			DefaultTimeout: parseDuration("GRPC_DEFAULT_TIMEOUT", 10*time.Second),
+		},
+		ShutdownTimeout:     parseDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		HealthCheckInterval: parseDuration("HEALTH_CHECK_INTERVAL", 10*time.Second),
+		LogLevel:            LogLevel,
+		FileLogLevel:        FileLogLevel,
+	}
+}
+
+// parseDuration читает длительность вида "15m" из переменной окружения, иначе возвращает def.
+func parseDuration(key string, def time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		logger.Errorf("Invalid %s=%q, using default %s", key, raw, def)
+		return def
+	}
+	return d
+}
+
+// parseInt читает положительное целое из переменной окружения, иначе возвращает def.
+func parseInt(key string, def int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		logger.Errorf("Invalid %s=%q, using default %d", key, raw, def)
+		return def
+	}
+	return n
+}
+
+// parseRatio читает долю от 0 до 1 из переменной окружения, иначе возвращает def.
+func parseRatio(key string, def float64) float64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil || f < 0 || f > 1 {
+		logger.Errorf("Invalid %s=%q, using default %g", key, raw, def)
+		return def
+	}
+	return f
+}
+
+func getEnv(key, def string) string {
+	if raw := os.Getenv(key); raw != "" {
+		return raw
+	}
+	return def
+}
+
+// dlqTopic читает имя DLQ-топика; по умолчанию это "<topic>.dlq".
+func dlqTopic(key, topic string) string {
+	if raw := os.Getenv(key); raw != "" {
+		return raw
+	}
+	if topic == "" {
+		return ""
 	}
+	return topic + ".dlq"
 }
 
 func parseKafkaTopics(s string) map[string]string {
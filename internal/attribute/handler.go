@@ -0,0 +1,190 @@
+package attribute
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ShopOnGO/product-service/internal/auth"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AttributeHandlerDeps struct {
+	AttributeSvc *AttributeService
+	Auth         gin.HandlerFunc // проверка JWT для изменяющих запросов
+}
+
+type AttributeHandler struct {
+	attributeSvc *AttributeService
+}
+
+func NewAttributeHandler(router *gin.Engine, deps AttributeHandlerDeps) *AttributeHandler {
+	handler := &AttributeHandler{
+		attributeSvc: deps.AttributeSvc,
+	}
+
+	categoryGroup := router.Group("/product-service/categories/:id/attributes")
+	{
+		categoryGroup.GET("", handler.ListDefinitions)
+		categoryGroup.POST("", deps.Auth, auth.RequireRoles(auth.RoleCatalogManager), handler.CreateDefinition)
+	}
+
+	attributeGroup := router.Group("/product-service/attributes")
+	{
+		attributeGroup.PUT("/:id", deps.Auth, auth.RequireRoles(auth.RoleCatalogManager), handler.UpdateDefinition)
+		attributeGroup.DELETE("/:id", deps.Auth, auth.RequireRoles(auth.RoleAdmin), handler.DeleteDefinition)
+	}
+
+	return handler
+}
+
+// ListDefinitions godoc
+// @Summary Атрибуты вариантов категории
+// @Description Определения атрибутов, действующие для категории, включая унаследованные от родительских
+// @Tags attributes
+// @Produce json
+// @Param id path int true "ID категории"
+// @Success 200 {array} Definition
+// @Failure 400 {object} gin.H "Неверный ID"
+// @Failure 404 {object} gin.H "Категория не найдена"
+// @Failure 500 {object} gin.H "Ошибка сервера"
+// @Router /categories/{id}/attributes [get]
+func (h *AttributeHandler) ListDefinitions(c *gin.Context) {
+	categoryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || categoryID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	definitions, err := h.attributeSvc.ListForCategory(c.Request.Context(), uint(categoryID))
+	if err != nil {
+		writeAttributeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, definitions)
+}
+
+// CreateDefinition godoc
+// @Summary Добавить атрибут категории
+// @Description Создаёт определение атрибута: enum со списком значений, color с hex-кодом или number с единицей
+// @Tags attributes
+// @Accept json
+// @Produce json
+// @Param id path int true "ID категории"
+// @Param definition body CreateDefinitionPayload true "Определение атрибута"
+// @Success 201 {object} Definition
+// @Failure 400 {object} gin.H "Неверный запрос"
+// @Failure 401 {object} gin.H "Требуется авторизация"
+// @Failure 403 {object} gin.H "Только admin и catalog-manager"
+// @Failure 404 {object} gin.H "Категория не найдена"
+// @Failure 500 {object} gin.H "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /categories/{id}/attributes [post]
+func (h *AttributeHandler) CreateDefinition(c *gin.Context) {
+	categoryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || categoryID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	var payload CreateDefinitionPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	definition, err := h.attributeSvc.CreateDefinition(c.Request.Context(), uint(categoryID), payload)
+	if err != nil {
+		writeAttributeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, definition)
+}
+
+// UpdateDefinition godoc
+// @Summary Изменить атрибут
+// @Description Меняет название, единицу, допустимые значения и флаги. Код и тип не меняются
+// @Tags attributes
+// @Accept json
+// @Produce json
+// @Param id path int true "ID атрибута"
+// @Param definition body UpdateDefinitionPayload true "Изменяемые поля"
+// @Success 200 {object} Definition
+// @Failure 400 {object} gin.H "Неверный запрос"
+// @Failure 401 {object} gin.H "Требуется авторизация"
+// @Failure 403 {object} gin.H "Только admin и catalog-manager"
+// @Failure 404 {object} gin.H "Атрибут не найден"
+// @Failure 500 {object} gin.H "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /attributes/{id} [put]
+func (h *AttributeHandler) UpdateDefinition(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attribute ID"})
+		return
+	}
+
+	var payload UpdateDefinitionPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	definition, err := h.attributeSvc.UpdateDefinition(c.Request.Context(), uint(id), payload)
+	if err != nil {
+		writeAttributeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, definition)
+}
+
+// DeleteDefinition godoc
+// @Summary Удалить атрибут
+// @Description Удаляет определение атрибута, если ни у одного варианта нет его значений
+// @Tags attributes
+// @Param id path int true "ID атрибута"
+// @Success 200 {object} gin.H "Атрибут удалён"
+// @Failure 400 {object} gin.H "Неверный ID"
+// @Failure 401 {object} gin.H "Требуется авторизация"
+// @Failure 403 {object} gin.H "Только admin"
+// @Failure 404 {object} gin.H "Атрибут не найден"
+// @Failure 409 {object} gin.H "У вариантов есть значения атрибута"
+// @Failure 500 {object} gin.H "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /attributes/{id} [delete]
+func (h *AttributeHandler) DeleteDefinition(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attribute ID"})
+		return
+	}
+
+	if err := h.attributeSvc.DeleteDefinition(c.Request.Context(), uint(id)); err != nil {
+		writeAttributeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "attribute deleted"})
+}
+
+// writeAttributeError переводит ошибки атрибутов в HTTP-статусы.
+func writeAttributeError(c *gin.Context, err error) {
+	if status, ok := ErrorStatus(err); ok {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// ErrorStatus возвращает HTTP-статус для ошибок пакета attribute; им пользуется и productVariant.
+func ErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, ErrCategoryNotFound):
+		return http.StatusNotFound, true
+	case errors.Is(err, ErrDefinitionInUse), errors.Is(err, ErrDuplicateCode):
+		return http.StatusConflict, true
+	case errors.Is(err, ErrUnknownAttribute), errors.Is(err, ErrInvalidAttribute), errors.Is(err, ErrInvalidDefinition):
+		return http.StatusBadRequest, true
+	}
+	return 0, false
+}
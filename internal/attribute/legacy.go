@@ -0,0 +1,294 @@
+package attribute
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Старые поля варианта: Sizes и Colors — списки через запятую, Dimensions — строка вида "20x30x5 см".
+// Они остаются в модели и событиях для совместимости и пересобираются из типизированных значений.
+
+const defaultDimensionUnit = "см"
+
+var (
+	listSeparators = regexp.MustCompile(`\s*[,;/]\s*`)
+	// Разделитель измерений — латинская или кириллическая "х", знак умножения или звёздочка.
+	dimensionsPattern = regexp.MustCompile(`^\s*(\d+(?:[.,]\d+)?)\s*[xXхХ×*]\s*(\d+(?:[.,]\d+)?)(?:\s*[xXхХ×*]\s*(\d+(?:[.,]\d+)?))?\s*([^\d\s].*)?$`)
+)
+
+var dimensionCodes = []string{CodeLength, CodeWidth, CodeHeight}
+
+// palette — hex-коды распространённых цветов для значений без явного hex.
+var palette = map[string]string{
+	"black": "#000000", "черный": "#000000", "чёрный": "#000000",
+	"white": "#FFFFFF", "белый": "#FFFFFF",
+	"red": "#FF0000", "красный": "#FF0000",
+	"green": "#008000", "зеленый": "#008000", "зелёный": "#008000",
+	"blue": "#0000FF", "синий": "#0000FF",
+	"light blue": "#ADD8E6", "голубой": "#ADD8E6",
+	"yellow": "#FFFF00", "желтый": "#FFFF00", "жёлтый": "#FFFF00",
+	"orange": "#FFA500", "оранжевый": "#FFA500",
+	"purple": "#800080", "фиолетовый": "#800080",
+	"pink": "#FFC0CB", "розовый": "#FFC0CB",
+	"brown": "#8B4513", "коричневый": "#8B4513",
+	"grey": "#808080", "gray": "#808080", "серый": "#808080",
+	"beige": "#F5F5DC", "бежевый": "#F5F5DC",
+	"navy": "#000080", "темно-синий": "#000080", "тёмно-синий": "#000080",
+	"silver": "#C0C0C0", "серебристый": "#C0C0C0",
+	"gold": "#FFD700", "золотой": "#FFD700",
+}
+
+// ColorHex возвращает hex-код известного цвета или пустую строку.
+func ColorHex(name string) string {
+	return palette[strings.ToLower(strings.TrimSpace(name))]
+}
+
+// ParseList разбирает список значений через запятую, точку с запятой или слэш.
+func ParseList(s string) []string {
+	var values []string
+	for _, v := range listSeparators.Split(strings.TrimSpace(s), -1) {
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// ParseDimensions разбирает "20x30x5 см" в числа и единицу; без единицы считаются сантиметры.
+func ParseDimensions(s string) ([]decimal.Decimal, string, bool) {
+	match := dimensionsPattern.FindStringSubmatch(s)
+	if match == nil {
+		return nil, "", false
+	}
+	var numbers []decimal.Decimal
+	for _, raw := range match[1:4] {
+		if raw == "" {
+			continue
+		}
+		n, err := decimal.NewFromString(strings.Replace(raw, ",", ".", 1))
+		if err != nil {
+			return nil, "", false
+		}
+		numbers = append(numbers, n)
+	}
+	unit := strings.TrimSuffix(strings.TrimSpace(match[4]), ".")
+	if unit == "" {
+		unit = defaultDimensionUnit
+	}
+	return numbers, unit, true
+}
+
+// LegacyInputs переводит старые строки варианта в значения атрибутов.
+func LegacyInputs(sizes, colors, dimensions string) []ValueInput {
+	var inputs []ValueInput
+	for _, size := range ParseList(sizes) {
+		inputs = append(inputs, ValueInput{Code: CodeSize, Value: size})
+	}
+	for _, color := range ParseList(colors) {
+		inputs = append(inputs, ValueInput{Code: CodeColor, Value: color, Hex: ColorHex(color)})
+	}
+	if numbers, _, ok := ParseDimensions(dimensions); ok {
+		for i, n := range numbers {
+			n := n
+			inputs = append(inputs, ValueInput{Code: dimensionCodes[i], Number: &n})
+		}
+	}
+	return inputs
+}
+
+// LegacyStrings собирает старые строки варианта из типизированных значений.
+// Габариты собираются в строку, только если заданы хотя бы длина и ширина.
+func LegacyStrings(values []VariantValue) (sizes, colors, dimensions string) {
+	var sizeList, colorList []string
+	dims := make(map[string]VariantValue, len(dimensionCodes))
+	for _, v := range values {
+		switch v.Code {
+		case CodeSize:
+			sizeList = append(sizeList, v.Value)
+		case CodeColor:
+			colorList = append(colorList, v.Value)
+		case CodeLength, CodeWidth, CodeHeight:
+			if v.Number != nil {
+				dims[v.Code] = v
+			}
+		}
+	}
+
+	parts := make([]string, 0, len(dimensionCodes))
+	unit := ""
+	for _, code := range dimensionCodes {
+		v, ok := dims[code]
+		if !ok {
+			break
+		}
+		parts = append(parts, v.Number.String())
+		unit = v.Unit
+	}
+	if len(parts) >= 2 {
+		dimensions = strings.Join(parts, "x")
+		if unit != "" {
+			dimensions += " " + unit
+		}
+	}
+	return strings.Join(sizeList, ", "), strings.Join(colorList, ", "), dimensions
+}
+
+// legacyVariant — вариант без типизированных атрибутов вместе с категорией его продукта.
+type legacyVariant struct {
+	ID         uint
+	CategoryID uint
+	Sizes      string
+	Colors     string
+	Dimensions string
+}
+
+const legacyVariantsSQL = `SELECT pv.id, p.category_id, pv.sizes, pv.colors, pv.dimensions
+FROM product_variants pv JOIN products p ON p.id = pv.product_id
+WHERE pv.deleted_at IS NULL AND pv.id > ?
+	AND NOT EXISTS (SELECT 1 FROM variant_attribute_values v WHERE v.variant_id = pv.id)
+	AND (coalesce(pv.sizes, '') <> '' OR coalesce(pv.colors, '') <> '' OR coalesce(pv.dimensions, '') <> '')
+ORDER BY pv.id LIMIT ?`
+
+const legacyBatchSize = 500
+
+// MigrateLegacy переносит старые строки Sizes, Colors и Dimensions в типизированные значения.
+// Категориям без нужных определений создаются size (enum из встреченных значений), color
+// и length/width/height; к существующим enum добавляются встреченные значения. Повторный запуск
+// обрабатывает только варианты без значений. Возвращает число перенесённых вариантов.
+func MigrateLegacy(db *gorm.DB) (int, error) {
+	migrated := 0
+	lastID := uint(0)
+	for {
+		var batch []legacyVariant
+		if err := db.Raw(legacyVariantsSQL, lastID, legacyBatchSize).Scan(&batch).Error; err != nil {
+			return migrated, err
+		}
+		if len(batch) == 0 {
+			return migrated, nil
+		}
+		lastID = batch[len(batch)-1].ID
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			n, err := migrateBatch(tx, batch)
+			migrated += n
+			return err
+		})
+		if err != nil {
+			return migrated, err
+		}
+	}
+}
+
+func migrateBatch(tx *gorm.DB, batch []legacyVariant) (int, error) {
+	byCategory := make(map[uint][]legacyVariant)
+	for _, v := range batch {
+		byCategory[v.CategoryID] = append(byCategory[v.CategoryID], v)
+	}
+
+	migrated := 0
+	for categoryID, variants := range byCategory {
+		definitions, err := ensureLegacyDefinitions(tx, categoryID, variants)
+		if err != nil {
+			return migrated, err
+		}
+		for _, v := range variants {
+			values, err := resolve(definitions, LegacyInputs(v.Sizes, v.Colors, v.Dimensions), true)
+			if err != nil {
+				// Строка не прошла проверку (например, обязательный атрибут без значения) —
+				// вариант остаётся со старыми строками, его исправят при следующем обновлении.
+				continue
+			}
+			if len(values) == 0 {
+				continue
+			}
+			if err := ReplaceValues(tx, v.ID, values); err != nil {
+				return migrated, err
+			}
+			migrated++
+		}
+	}
+	return migrated, nil
+}
+
+// ensureLegacyDefinitions создаёт недостающие определения для старых строк категории
+// и расширяет AllowedValues существующих enum встреченными значениями.
+func ensureLegacyDefinitions(tx *gorm.DB, categoryID uint, variants []legacyVariant) ([]Definition, error) {
+	definitions, err := listForCategory(tx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]*Definition, len(definitions))
+	for i := range definitions {
+		byCode[definitions[i].Code] = &definitions[i]
+	}
+
+	seen := make(map[string][]string)
+	dimensionUnit, dimensionCount := "", 0
+	for _, v := range variants {
+		seen[CodeSize] = append(seen[CodeSize], ParseList(v.Sizes)...)
+		seen[CodeColor] = append(seen[CodeColor], ParseList(v.Colors)...)
+		if numbers, unit, ok := ParseDimensions(v.Dimensions); ok {
+			if len(numbers) > dimensionCount {
+				dimensionCount = len(numbers)
+			}
+			if dimensionUnit == "" {
+				dimensionUnit = unit
+			}
+		}
+	}
+
+	wanted := []Definition{
+		{Code: CodeSize, Name: "Размер", Type: TypeEnum, Multiple: true},
+		{Code: CodeColor, Name: "Цвет", Type: TypeColor, Multiple: true},
+	}
+	names := map[string]string{CodeLength: "Длина", CodeWidth: "Ширина", CodeHeight: "Высота"}
+	for _, code := range dimensionCodes[:dimensionCount] {
+		wanted = append(wanted, Definition{Code: code, Name: names[code], Type: TypeNumber, Unit: dimensionUnit})
+	}
+
+	changed := false
+	for _, w := range wanted {
+		values := seen[w.Code]
+		if w.Type != TypeNumber && len(values) == 0 {
+			continue
+		}
+		existing, ok := byCode[w.Code]
+		if !ok {
+			w.CategoryID = categoryID
+			if w.Type == TypeEnum {
+				w.AllowedValues = sortedUnique(values)
+			}
+			if err := tx.Create(&w).Error; err != nil {
+				return nil, err
+			}
+			changed = true
+			continue
+		}
+		if existing.Type != TypeNumber && len(existing.AllowedValues) > 0 {
+			merged := normalizeAllowed(append(append([]string{}, existing.AllowedValues...), values...))
+			if len(merged) != len(existing.AllowedValues) {
+				if err := tx.Model(&Definition{}).Where("id = ?", existing.ID).
+					Update("allowed_values", pq.StringArray(merged)).Error; err != nil {
+					return nil, err
+				}
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return definitions, nil
+	}
+	return listForCategory(tx, categoryID)
+}
+
+func sortedUnique(values []string) []string {
+	unique := normalizeAllowed(values)
+	sort.Strings(unique)
+	return unique
+}
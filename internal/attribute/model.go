@@ -0,0 +1,65 @@
+package attribute
+
+import (
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ValueType — тип значения атрибута.
+type ValueType string
+
+const (
+	TypeEnum   ValueType = "enum"   // значение из списка AllowedValues (пустой список — любое)
+	TypeColor  ValueType = "color"  // название цвета и его hex-код
+	TypeNumber ValueType = "number" // число в единицах Unit
+)
+
+// Коды атрибутов, в которые переносятся старые строки Sizes, Colors и Dimensions.
+const (
+	CodeSize   = "size"
+	CodeColor  = "color"
+	CodeLength = "length"
+	CodeWidth  = "width"
+	CodeHeight = "height"
+)
+
+// Definition — атрибут вариантов категории. Действует и для подкатегорий;
+// определение с тем же кодом в подкатегории перекрывает родительское.
+type Definition struct {
+	gorm.Model    `swaggerignore:"true"`
+	CategoryID    uint           `gorm:"not null;uniqueIndex:idx_attribute_definitions_category_code,where:deleted_at IS NULL" json:"category_id"`
+	Code          string         `gorm:"type:varchar(50);not null;uniqueIndex:idx_attribute_definitions_category_code,where:deleted_at IS NULL" json:"code"`
+	Name          string         `gorm:"type:varchar(100);not null" json:"name"`
+	Type          ValueType      `gorm:"type:varchar(20);not null" json:"type"`
+	Unit          string         `gorm:"type:varchar(20)" json:"unit,omitempty"`
+	AllowedValues pq.StringArray `gorm:"type:text[]" json:"allowed_values,omitempty"`
+	Required      bool           `gorm:"not null;default:false" json:"required"`
+	Multiple      bool           `gorm:"not null;default:false" json:"multiple"` // можно ли указать несколько значений
+}
+
+func (Definition) TableName() string {
+	return "attribute_definitions"
+}
+
+// VariantValue — значение атрибута у варианта. Code и Unit копируются из определения,
+// чтобы значения читались без join'а. Значения варианта заменяются целиком, поэтому без мягкого удаления.
+type VariantValue struct {
+	ID           uint             `gorm:"primaryKey" json:"-"`
+	VariantID    uint             `gorm:"not null;index" json:"-"`
+	DefinitionID uint             `gorm:"not null;index" json:"definition_id"`
+	Code         string           `gorm:"type:varchar(50);not null" json:"code"`
+	Value        string           `gorm:"type:varchar(100)" json:"value,omitempty"`
+	Hex          string           `gorm:"type:varchar(7)" json:"hex,omitempty"`
+	Number       *decimal.Decimal `gorm:"type:decimal(12,3)" json:"number,omitempty"`
+	Unit         string           `gorm:"type:varchar(20)" json:"unit,omitempty"`
+}
+
+func (VariantValue) TableName() string {
+	return "variant_attribute_values"
+}
+
+// Input возвращает значение в виде входных данных — для повторной проверки.
+func (v VariantValue) Input() ValueInput {
+	return ValueInput{Code: v.Code, Value: v.Value, Hex: v.Hex, Number: v.Number}
+}
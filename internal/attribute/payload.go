@@ -0,0 +1,31 @@
+package attribute
+
+import "github.com/shopspring/decimal"
+
+// ValueInput — значение атрибута варианта в запросе. Для enum заполняется value,
+// для color — value и hex (для известных цветов hex подставляется сам), для number — number.
+type ValueInput struct {
+	Code   string           `json:"code"`
+	Value  string           `json:"value,omitempty"`
+	Hex    string           `json:"hex,omitempty"`
+	Number *decimal.Decimal `json:"number,omitempty"`
+}
+
+type CreateDefinitionPayload struct {
+	Code          string    `json:"code" binding:"required,max=50"`
+	Name          string    `json:"name" binding:"required,max=100"`
+	Type          ValueType `json:"type" binding:"required,oneof=enum color number"`
+	Unit          string    `json:"unit" binding:"max=20"`
+	AllowedValues []string  `json:"allowed_values"`
+	Required      bool      `json:"required"`
+	Multiple      bool      `json:"multiple"`
+}
+
+// UpdateDefinitionPayload — код и тип не меняются: на них опираются сохранённые значения.
+type UpdateDefinitionPayload struct {
+	Name          *string   `json:"name" binding:"omitempty,max=100"`
+	Unit          *string   `json:"unit" binding:"omitempty,max=20"`
+	AllowedValues *[]string `json:"allowed_values"`
+	Required      *bool     `json:"required"`
+	Multiple      *bool     `json:"multiple"`
+}
@@ -0,0 +1,140 @@
+package attribute
+
+import (
+	"context"
+
+	"github.com/ShopOnGO/product-service/internal/audit"
+	"github.com/ShopOnGO/product-service/pkg/db"
+	"gorm.io/gorm"
+)
+
+const auditEntity = "attribute"
+
+// effectiveDefinitionsSQL выбирает определения категории и её предков;
+// при совпадении кода побеждает ближайшая к категории.
+const effectiveDefinitionsSQL = `WITH RECURSIVE chain AS (
+	SELECT id, parent_category_id, 0 AS depth FROM categories WHERE id = ? AND deleted_at IS NULL
+	UNION ALL
+	SELECT c.id, c.parent_category_id, ch.depth + 1 FROM categories c
+	JOIN chain ch ON c.id = ch.parent_category_id
+	WHERE c.deleted_at IS NULL AND ch.depth < 64
+) SELECT DISTINCT ON (d.code) d.* FROM attribute_definitions d
+JOIN chain ch ON ch.id = d.category_id
+WHERE d.deleted_at IS NULL
+ORDER BY d.code, ch.depth`
+
+type AttributeRepository struct {
+	Db *db.Db
+}
+
+func NewAttributeRepository(db *db.Db) *AttributeRepository {
+	return &AttributeRepository{
+		Db: db,
+	}
+}
+
+// ListForCategory возвращает определения, действующие для категории, с учётом унаследованных.
+func (repo *AttributeRepository) ListForCategory(ctx context.Context, categoryID uint) ([]Definition, error) {
+	return listForCategory(repo.Db.WithContext(ctx), categoryID)
+}
+
+func listForCategory(tx *gorm.DB, categoryID uint) ([]Definition, error) {
+	var definitions []Definition
+	err := db.RawScan(tx, &definitions, effectiveDefinitionsSQL, categoryID)
+	return definitions, err
+}
+
+func (repo *AttributeRepository) GetDefinition(ctx context.Context, id uint) (*Definition, error) {
+	var definition Definition
+	if err := repo.Db.WithContext(ctx).First(&definition, id).Error; err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// CategoryExists читает таблицу категорий напрямую, как это делает productVariant для продуктов.
+func (repo *AttributeRepository) CategoryExists(ctx context.Context, categoryID uint) (bool, error) {
+	var count int64
+	err := repo.Db.WithContext(ctx).
+		Table("categories").
+		Where("id = ? AND deleted_at IS NULL", categoryID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CreateDefinition, UpdateDefinition и DeleteDefinition пишут запись в журнал аудита в той же транзакции.
+func (repo *AttributeRepository) CreateDefinition(ctx context.Context, definition *Definition) (*Definition, error) {
+	err := repo.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(definition).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntity, definition.ID, "create", definition)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
+
+func (repo *AttributeRepository) UpdateDefinition(ctx context.Context, definition *Definition) (*Definition, error) {
+	err := repo.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Definition{}).
+			Where("id = ?", definition.ID).
+			Select("name", "unit", "allowed_values", "required", "multiple").
+			Updates(definition).Error; err != nil {
+			return err
+		}
+		// Единица копируется в значения, поэтому меняется вместе с определением.
+		if err := tx.Model(&VariantValue{}).
+			Where("definition_id = ?", definition.ID).
+			Update("unit", definition.Unit).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntity, definition.ID, "update", definition)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
+
+// DeleteDefinition удаляет определение, по которому не сохранено ни одного значения.
+func (repo *AttributeRepository) DeleteDefinition(ctx context.Context, id uint) error {
+	return repo.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var used int64
+		if err := tx.Model(&VariantValue{}).Where("definition_id = ?", id).Count(&used).Error; err != nil {
+			return err
+		}
+		if used > 0 {
+			return ErrDefinitionInUse
+		}
+		if err := tx.Delete(&Definition{}, id).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntity, id, "delete", nil)
+	})
+}
+
+// Resolve — AttributeService.Resolve в транзакции вызывающего: определения категории читаются через tx.
+func Resolve(tx *gorm.DB, categoryID uint, inputs []ValueInput, legacy bool) ([]VariantValue, error) {
+	definitions, err := listForCategory(tx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	return resolve(definitions, inputs, legacy)
+}
+
+// ReplaceValues заменяет значения атрибутов варианта в транзакции вызывающего.
+func ReplaceValues(tx *gorm.DB, variantID uint, values []VariantValue) error {
+	if err := tx.Where("variant_id = ?", variantID).Delete(&VariantValue{}).Error; err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	for i := range values {
+		values[i].ID = 0
+		values[i].VariantID = variantID
+	}
+	return tx.Create(&values).Error
+}
@@ -0,0 +1,258 @@
+package attribute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrUnknownAttribute  = errors.New("unknown attribute")
+	ErrInvalidAttribute  = errors.New("invalid attribute value")
+	ErrInvalidDefinition = errors.New("invalid attribute definition")
+	ErrDefinitionInUse   = errors.New("attribute definition is used by variants")
+	ErrDuplicateCode     = errors.New("attribute with this code already exists in the category")
+	ErrCategoryNotFound  = errors.New("category not found")
+)
+
+var (
+	codePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	hexPattern  = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+type AttributeService struct {
+	repo *AttributeRepository
+}
+
+func NewAttributeService(repo *AttributeRepository) *AttributeService {
+	return &AttributeService{
+		repo: repo,
+	}
+}
+
+// ListForCategory возвращает определения, действующие для категории, с учётом унаследованных.
+func (s *AttributeService) ListForCategory(ctx context.Context, categoryID uint) ([]Definition, error) {
+	exists, err := s.repo.CategoryExists(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrCategoryNotFound
+	}
+	return s.repo.ListForCategory(ctx, categoryID)
+}
+
+func (s *AttributeService) CreateDefinition(ctx context.Context, categoryID uint, payload CreateDefinitionPayload) (*Definition, error) {
+	exists, err := s.repo.CategoryExists(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrCategoryNotFound
+	}
+
+	definition := &Definition{
+		CategoryID:    categoryID,
+		Code:          strings.ToLower(strings.TrimSpace(payload.Code)),
+		Name:          payload.Name,
+		Type:          payload.Type,
+		Unit:          payload.Unit,
+		AllowedValues: normalizeAllowed(payload.AllowedValues),
+		Required:      payload.Required,
+		Multiple:      payload.Multiple,
+	}
+	if err := validateDefinition(definition); err != nil {
+		return nil, err
+	}
+
+	// Унаследованный код можно перекрыть, а повторить код самой категории — нет.
+	existing, err := s.repo.ListForCategory(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range existing {
+		if e.Code == definition.Code && e.CategoryID == categoryID {
+			return nil, ErrDuplicateCode
+		}
+	}
+	return s.repo.CreateDefinition(ctx, definition)
+}
+
+func (s *AttributeService) UpdateDefinition(ctx context.Context, id uint, payload UpdateDefinitionPayload) (*Definition, error) {
+	definition, err := s.repo.GetDefinition(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payload.Name != nil {
+		definition.Name = *payload.Name
+	}
+	if payload.Unit != nil {
+		definition.Unit = *payload.Unit
+	}
+	if payload.AllowedValues != nil {
+		definition.AllowedValues = normalizeAllowed(*payload.AllowedValues)
+	}
+	if payload.Required != nil {
+		definition.Required = *payload.Required
+	}
+	if payload.Multiple != nil {
+		definition.Multiple = *payload.Multiple
+	}
+	// Сужение AllowedValues не трогает сохранённые значения: проверка применяется к новым записям.
+	if err := validateDefinition(definition); err != nil {
+		return nil, err
+	}
+	return s.repo.UpdateDefinition(ctx, definition)
+}
+
+func (s *AttributeService) DeleteDefinition(ctx context.Context, id uint) error {
+	if _, err := s.repo.GetDefinition(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteDefinition(ctx, id)
+}
+
+// Resolve проверяет значения атрибутов варианта по определениям категории и возвращает их
+// готовыми к сохранению. legacy — значения разобраны из старых строк Sizes/Colors/Dimensions:
+// коды без определения в категории пропускаются, а неизвестный цвет допускается без hex.
+func (s *AttributeService) Resolve(ctx context.Context, categoryID uint, inputs []ValueInput, legacy bool) ([]VariantValue, error) {
+	definitions, err := s.repo.ListForCategory(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	return resolve(definitions, inputs, legacy)
+}
+
+func resolve(definitions []Definition, inputs []ValueInput, legacy bool) ([]VariantValue, error) {
+	byCode := make(map[string]*Definition, len(definitions))
+	for i := range definitions {
+		byCode[definitions[i].Code] = &definitions[i]
+	}
+
+	values := make([]VariantValue, 0, len(inputs))
+	counts := make(map[string]int, len(inputs))
+	seen := make(map[string]bool, len(inputs))
+	for _, input := range inputs {
+		code := strings.ToLower(strings.TrimSpace(input.Code))
+		definition, ok := byCode[code]
+		if !ok {
+			if legacy {
+				continue
+			}
+			return nil, fmt.Errorf("%w: %q", ErrUnknownAttribute, input.Code)
+		}
+
+		value, err := validateValue(definition, input, legacy)
+		if err != nil {
+			return nil, err
+		}
+		key := code + "\x00" + value.Value
+		if value.Number != nil {
+			key += "\x00" + value.Number.String()
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if counts[code]++; counts[code] > 1 && !definition.Multiple {
+			return nil, fmt.Errorf("%w: %s accepts a single value", ErrInvalidAttribute, code)
+		}
+		values = append(values, value)
+	}
+
+	for _, definition := range definitions {
+		if definition.Required && counts[definition.Code] == 0 {
+			return nil, fmt.Errorf("%w: %s is required", ErrInvalidAttribute, definition.Code)
+		}
+	}
+	return values, nil
+}
+
+func validateValue(definition *Definition, input ValueInput, legacy bool) (VariantValue, error) {
+	value := VariantValue{
+		DefinitionID: definition.ID,
+		Code:         definition.Code,
+		Unit:         definition.Unit,
+	}
+
+	switch definition.Type {
+	case TypeEnum, TypeColor:
+		name := strings.TrimSpace(input.Value)
+		if name == "" {
+			return value, fmt.Errorf("%w: %s needs a value", ErrInvalidAttribute, definition.Code)
+		}
+		if len(definition.AllowedValues) > 0 {
+			allowed, ok := findAllowed(definition.AllowedValues, name)
+			if !ok {
+				return value, fmt.Errorf("%w: %q is not allowed for %s", ErrInvalidAttribute, name, definition.Code)
+			}
+			name = allowed
+		}
+		value.Value = name
+
+		if definition.Type == TypeColor {
+			hex := strings.TrimSpace(input.Hex)
+			if hex == "" {
+				hex = ColorHex(name)
+			}
+			if hex != "" && !hexPattern.MatchString(hex) {
+				return value, fmt.Errorf("%w: %q is not a #RRGGBB color", ErrInvalidAttribute, hex)
+			}
+			if hex == "" && !legacy {
+				return value, fmt.Errorf("%w: color %q needs a hex code", ErrInvalidAttribute, name)
+			}
+			value.Hex = strings.ToUpper(hex)
+		}
+	case TypeNumber:
+		if input.Number == nil || input.Number.IsNegative() {
+			return value, fmt.Errorf("%w: %s needs a non-negative number", ErrInvalidAttribute, definition.Code)
+		}
+		number := *input.Number
+		value.Number = &number
+	default:
+		return value, fmt.Errorf("%w: unsupported type %q", ErrInvalidDefinition, definition.Type)
+	}
+	return value, nil
+}
+
+func validateDefinition(definition *Definition) error {
+	if !codePattern.MatchString(definition.Code) {
+		return fmt.Errorf("%w: code must be lowercase latin letters, digits and underscores", ErrInvalidDefinition)
+	}
+	switch definition.Type {
+	case TypeEnum, TypeColor:
+	case TypeNumber:
+		if len(definition.AllowedValues) > 0 {
+			return fmt.Errorf("%w: allowed_values are not supported for numbers", ErrInvalidDefinition)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported type %q", ErrInvalidDefinition, definition.Type)
+	}
+	return nil
+}
+
+func findAllowed(allowed []string, value string) (string, bool) {
+	for _, a := range allowed {
+		if strings.EqualFold(a, value) {
+			return a, true
+		}
+	}
+	return "", false
+}
+
+func normalizeAllowed(values []string) []string {
+	normalized := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if _, ok := findAllowed(normalized, v); !ok {
+			normalized = append(normalized, v)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/ShopOnGO/product-service/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandlerDeps struct {
+	AuditSvc *AuditService
+	Auth     gin.HandlerFunc // проверка JWT
+}
+
+type AuditHandler struct {
+	auditSvc *AuditService
+}
+
+func NewAuditHandler(router *gin.Engine, deps AuditHandlerDeps) *AuditHandler {
+	handler := &AuditHandler{
+		auditSvc: deps.AuditSvc,
+	}
+
+	auditGroup := router.Group("/product-service/admin/audit-log", deps.Auth, auth.RequireRoles(auth.RoleAdmin))
+	{
+		auditGroup.GET("", handler.ListEntries)
+	}
+
+	return handler
+}
+
+// ListEntries возвращает журнал изменений справочников.
+// @Summary Журнал изменений
+// @Description Кто и когда создавал, менял и удалял категории и бренды, повторял dead letters. Новые первыми.
+// @Tags Админка
+// @Produce json
+// @Param entity query string false "Сущность: category, brand, attribute, campaign, dead_letter"
+// @Param entity_id query int false "ID сущности"
+// @Param actor_id query int false "ID пользователя"
+// @Param from query string false "Начало периода (RFC 3339)"
+// @Param to query string false "Конец периода (RFC 3339)"
+// @Param page query int false "Номер страницы"
+// @Param page_size query int false "Размер страницы (до 100)"
+// @Success 200 {object} EntryPage
+// @Failure 400 {object} map[string]string "Неверные параметры"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Только для администратора"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /admin/audit-log [get]
+func (h *AuditHandler) ListEntries(c *gin.Context) {
+	var q EntryListQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	page, err := h.auditSvc.List(c.Request.Context(), q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
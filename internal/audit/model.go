@@ -0,0 +1,19 @@
+package audit
+
+import "time"
+
+// Entry — запись журнала изменений справочников: кто, что и с какими данными изменил.
+type Entry struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ActorID   uint      `gorm:"index;not null" json:"actor_id"` // 0 — изменение без пользователя (миграции, системные задачи)
+	ActorRole string    `gorm:"type:varchar(50);not null" json:"actor_role"`
+	Entity    string    `gorm:"type:varchar(50);not null;index:idx_audit_entity" json:"entity"` // category, brand, dead_letter
+	EntityID  uint      `gorm:"not null;index:idx_audit_entity" json:"entity_id"`
+	Action    string    `gorm:"type:varchar(50);not null" json:"action"` // create, update, delete, replay
+	Details   string    `gorm:"type:text" json:"-"`                      // JSON с данными изменения
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (Entry) TableName() string {
+	return "audit_log"
+}
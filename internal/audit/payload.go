@@ -0,0 +1,29 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EntryView — запись журнала для админки с разобранными деталями.
+type EntryView struct {
+	Entry
+	Details json.RawMessage `json:"details,omitempty" swaggertype:"object"`
+}
+
+type EntryListQuery struct {
+	Entity   string    `form:"entity"`
+	EntityID uint      `form:"entity_id"`
+	ActorID  uint      `form:"actor_id"`
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page     int       `form:"page"`
+	PageSize int       `form:"page_size"`
+}
+
+type EntryPage struct {
+	Items    []EntryView `json:"items"`
+	Total    int64       `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+}
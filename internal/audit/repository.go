@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ShopOnGO/product-service/internal/auth"
+	"github.com/ShopOnGO/product-service/pkg/db"
+	"gorm.io/gorm"
+)
+
+const systemActor = "system"
+
+type AuditRepository struct {
+	Database *db.Db
+}
+
+func NewAuditRepository(database *db.Db) *AuditRepository {
+	return &AuditRepository{
+		Database: database,
+	}
+}
+
+// Record пишет запись журнала внутри переданной транзакции, чтобы изменение
+// и его след фиксировались вместе. Пользователь берётся из контекста транзакции.
+func Record(tx *gorm.DB, entity string, entityID uint, action string, details interface{}) error {
+	entry := Entry{
+		ActorRole: systemActor,
+		Entity:    entity,
+		EntityID:  entityID,
+		Action:    action,
+	}
+	if actor, ok := auth.ActorFromContext(tx.Statement.Context); ok {
+		entry.ActorID = actor.UserID
+		entry.ActorRole = actor.Role
+	}
+	if details != nil {
+		raw, err := json.Marshal(details)
+		if err != nil {
+			return fmt.Errorf("marshal audit details for %s %d: %w", entity, entityID, err)
+		}
+		entry.Details = string(raw)
+	}
+	return tx.Create(&entry).Error
+}
+
+// List возвращает страницу записей, новые первыми.
+func (repo *AuditRepository) List(ctx context.Context, q EntryListQuery, limit, offset int) ([]Entry, int64, error) {
+	query := repo.Database.DB.WithContext(ctx).Model(&Entry{})
+	if q.Entity != "" {
+		query = query.Where("entity = ?", q.Entity)
+	}
+	if q.EntityID != 0 {
+		query = query.Where("entity_id = ?", q.EntityID)
+	}
+	if q.ActorID != 0 {
+		query = query.Where("actor_id = ?", q.ActorID)
+	}
+	if !q.From.IsZero() {
+		query = query.Where("created_at >= ?", q.From)
+	}
+	if !q.To.IsZero() {
+		query = query.Where("created_at < ?", q.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []Entry
+	err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&entries).Error
+	return entries, total, err
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+// Роли ShopOnGO, которые различает сервис. Пользователь без токена — публичный доступ:
+// ему открыты только чтения.
+//   - admin: всё, включая удаление категорий и брендов и служебные /admin-маршруты;
+//   - catalog-manager: создание и изменение категорий и брендов;
+//   - seller: свои продукты и их варианты;
+//   - service: внутренние сервисы ShopOnGO (заказы, корзина) — бронирование и списание остатков.
+const (
+	RoleAdmin          = "admin"
+	RoleCatalogManager = "catalog-manager"
+	RoleSeller         = "seller"
+	RoleService        = "service"
+)
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrForbidden       = errors.New("forbidden")
+)
+
+// Actor — пользователь, от имени которого выполняется изменение: из JWT для REST
+// или из user_id события для Kafka.
+type Actor struct {
+	UserID uint
+	Role   string
+}
+
+func (a Actor) IsAdmin() bool {
+	return a.Role == RoleAdmin
+}
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// WithoutActor убирает пользователя из контекста: код, выполняемый от имени системы
+// (например, повтор сообщения Kafka из админки), не должен наследовать права вызвавшего.
+func WithoutActor(ctx context.Context) context.Context {
+	return context.WithValue(ctx, actorKey{}, nil)
+}
+
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
+
+// RequireRole пропускает администратора и пользователей с одной из перечисленных ролей.
+func RequireRole(ctx context.Context, roles ...string) (Actor, error) {
+	actor, ok := ActorFromContext(ctx)
+	if !ok || actor.UserID == 0 {
+		return Actor{}, ErrUnauthenticated
+	}
+	if actor.IsAdmin() {
+		return actor, nil
+	}
+	for _, role := range roles {
+		if actor.Role == role {
+			return actor, nil
+		}
+	}
+	return Actor{}, ErrForbidden
+}
+
+// RequireOwner пропускает администратора и продавца-владельца ресурса.
+// Ресурс без владельца (ownerID == 0) может менять только администратор.
+func RequireOwner(ctx context.Context, ownerID uint) (Actor, error) {
+	actor, ok := ActorFromContext(ctx)
+	if !ok || actor.UserID == 0 {
+		return Actor{}, ErrUnauthenticated
+	}
+	if actor.IsAdmin() || (ownerID != 0 && actor.UserID == ownerID) {
+		return actor, nil
+	}
+	return Actor{}, ErrForbidden
+}
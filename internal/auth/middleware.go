@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/jwt"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware проверяет Bearer-токен, выпущенный ShopOnGO, и кладёт Actor в контекст запроса.
+// Без настроенного секрета все запросы отклоняются: пустой ключ подписи принял бы любой токен.
+func Middleware(secret string) gin.HandlerFunc {
+	tokens := jwt.NewJWT(secret)
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if secret == "" || !strings.HasPrefix(header, "Bearer ") {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": ErrUnauthenticated.Error()})
+			return
+		}
+
+		valid, data, err := tokens.Parse(strings.TrimPrefix(header, "Bearer "))
+		if err != nil || !valid {
+			logger.Warnf("Invalid token for %s %s: %v", c.Request.Method, c.FullPath(), err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		actor := Actor{UserID: data.UserID, Role: data.Role}
+		c.Request = c.Request.WithContext(WithActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
+
+// RequireRoles пропускает администратора и перечисленные роли; ставится после Middleware.
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := RequireRole(c.Request.Context(), roles...); err != nil {
+			status := http.StatusForbidden
+			if errors.Is(err, ErrUnauthenticated) {
+				status = http.StatusUnauthorized
+			}
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		c.Next()
+	}
+}
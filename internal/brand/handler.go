@@ -1,10 +1,13 @@
 package brand
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
+	"github.com/ShopOnGO/product-service/internal/auth"
+	"github.com/ShopOnGO/product-service/pkg/interfaces"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -18,6 +21,7 @@ type BrandHandler struct {
 type BrandHandlerDeps struct {
 	BrandSvc *BrandService
 	Kafka    *kafkaService.KafkaService
+	Auth     gin.HandlerFunc // проверка JWT для изменяющих запросов
 }
 
 func NewBrandHandler(router *gin.Engine, deps BrandHandlerDeps) *BrandHandler {
@@ -30,9 +34,9 @@ func NewBrandHandler(router *gin.Engine, deps BrandHandlerDeps) *BrandHandler {
 	{
 		brandGroup.GET("/", handler.GetBrands)
 		brandGroup.GET("/:id", handler.GetBrandByID)
-		brandGroup.POST("/", handler.CreateBrand)
-		brandGroup.PUT("/:id", handler.UpdateBrand)
-		brandGroup.DELETE("/:id", handler.DeleteBrand)
+		brandGroup.POST("/", deps.Auth, auth.RequireRoles(auth.RoleCatalogManager), handler.CreateBrand)
+		brandGroup.PUT("/:id", deps.Auth, auth.RequireRoles(auth.RoleCatalogManager), handler.UpdateBrand)
+		brandGroup.DELETE("/:id", deps.Auth, auth.RequireRoles(auth.RoleAdmin), handler.DeleteBrand)
 	}
 
 	return handler
@@ -46,7 +50,7 @@ func NewBrandHandler(router *gin.Engine, deps BrandHandlerDeps) *BrandHandler {
 // @Failure 500 {object} gin.H "Ошибка при получении брендов"
 // @Router /brands/ [get]
 func (h *BrandHandler) GetBrands(c *gin.Context) {
-	brands, err := h.brandSvc.GetAllBrands()
+	brands, err := h.brandSvc.GetAllBrands(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch brands"})
 		return
@@ -69,7 +73,7 @@ func (h *BrandHandler) GetBrandByID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid brand id"})
 		return
 	}
-	brand, err := h.brandSvc.GetBrandByID(uint(id))
+	brand, err := h.brandSvc.GetBrandByID(c.Request.Context(), uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -87,6 +91,9 @@ func (h *BrandHandler) GetBrandByID(c *gin.Context) {
 // @Success 201 {object} brand.Brand
 // @Failure 400 {object} gin.H "Некорректный формат запроса"
 // @Failure 500 {object} gin.H "Ошибка при создании бренда"
+// @Failure 401 {object} gin.H "Требуется авторизация"
+// @Failure 403 {object} gin.H "Только admin и catalog-manager"
+// @Security ApiKeyAuth
 // @Router /brands/ [post]
 func (h *BrandHandler) CreateBrand(c *gin.Context) {
 	var payload BrandRequest
@@ -102,7 +109,7 @@ func (h *BrandHandler) CreateBrand(c *gin.Context) {
 		Logo:        payload.Logo,
 	}
 
-	createdBrand, err := h.brandSvc.CreateBrand(newBrand)
+	createdBrand, err := h.brandSvc.CreateBrand(c.Request.Context(), newBrand)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create brand"})
 		return
@@ -133,6 +140,9 @@ func (h *BrandHandler) CreateBrand(c *gin.Context) {
 // @Success 200 {object} brand.Brand
 // @Failure 400 {object} gin.H "Некорректный формат запроса"
 // @Failure 500 {object} gin.H "Ошибка при обновлении бренда"
+// @Failure 401 {object} gin.H "Требуется авторизация"
+// @Failure 403 {object} gin.H "Только admin и catalog-manager"
+// @Security ApiKeyAuth
 // @Router /brands/{id} [put]
 func (h *BrandHandler) UpdateBrand(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -155,7 +165,7 @@ func (h *BrandHandler) UpdateBrand(c *gin.Context) {
 		Logo:        payload.Logo,
 	}
 
-	updatedBrand, err := h.brandSvc.UpdateBrand(newBrand)
+	updatedBrand, err := h.brandSvc.UpdateBrand(c.Request.Context(), newBrand)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -177,12 +187,20 @@ func (h *BrandHandler) UpdateBrand(c *gin.Context) {
 
 // DeleteBrand godoc
 // @Summary Удалить бренд
-// @Description Удаляет бренд по ID
+// @Description Удаляет бренд по ID. Если у бренда есть продукты, нужна стратегия:
+// @Description reassign — перенести на target_id, deactivate — снять с продажи, force — удалить вместе с брендом
 // @Tags Бренды
 // @Param id path int true "ID бренда"
-// @Success 200 {object} gin.H "Сообщение об успешном удалении"
-// @Failure 400 {object} gin.H "Некорректный ID бренда"
+// @Param strategy query string false "Стратегия для продуктов" Enums(reassign, deactivate, force)
+// @Param target_id query int false "Бренд для стратегии reassign"
+// @Success 200 {object} gin.H "Сообщение об удалении и affected_products"
+// @Failure 400 {object} gin.H "Некорректный ID, стратегия или target_id"
+// @Failure 404 {object} gin.H "Бренд не найден"
+// @Failure 409 {object} gin.H "У бренда есть продукты, стратегия не выбрана"
 // @Failure 500 {object} gin.H "Ошибка при удалении бренда"
+// @Failure 401 {object} gin.H "Требуется авторизация"
+// @Failure 403 {object} gin.H "Только admin"
+// @Security ApiKeyAuth
 // @Router /brands/{id} [delete]
 func (h *BrandHandler) DeleteBrand(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -191,8 +209,24 @@ func (h *BrandHandler) DeleteBrand(c *gin.Context) {
 		return
 	}
 
-	if err := h.brandSvc.DeleteBrand(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var query DeleteBrandQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	affected, err := h.brandSvc.DeleteBrand(c.Request.Context(), uint(id), query)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "brand not found"})
+		case errors.Is(err, interfaces.ErrHasProducts):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, interfaces.ErrUnknownStrategy), errors.Is(err, ErrReassignTarget):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -206,7 +240,7 @@ func (h *BrandHandler) DeleteBrand(c *gin.Context) {
 	// 		"message": fmt.Sprintf("Бренд '%v' был удалён.", id),
 	// 	},
 	// )
-	c.JSON(http.StatusOK, gin.H{"message": "brand deleted"})
+	c.JSON(http.StatusOK, gin.H{"message": "brand deleted", "affected_products": affected})
 }
 
 // func (h *BrandHandler) sendNotification(
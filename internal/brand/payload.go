@@ -1,9 +1,17 @@
 package brand
 
+import "github.com/ShopOnGO/product-service/pkg/interfaces"
+
 type BrandRequest struct {
 	ID          uint   `json:"id,omitempty"` // для update или delete
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	VideoURL    string `json:"video_url"`
 	Logo        string `json:"logo"`
-}
\ No newline at end of file
+}
+
+// DeleteBrandQuery — что сделать с продуктами бренда при удалении.
+type DeleteBrandQuery struct {
+	Strategy interfaces.DeleteStrategy `form:"strategy"`  // пусто — отказать, если продукты есть
+	TargetID uint                      `form:"target_id"` // бренд для стратегии reassign
+}
@@ -1,11 +1,17 @@
 package brand
 
 import (
+	"context"
 	"errors"
 
+	"github.com/ShopOnGO/product-service/internal/audit"
 	"github.com/ShopOnGO/product-service/pkg/db"
+	"github.com/ShopOnGO/product-service/pkg/interfaces"
+	"gorm.io/gorm"
 )
 
+const auditEntity = "brand"
+
 type BrandRepository struct {
 	Db *db.Db
 }
@@ -16,46 +22,80 @@ func NewBrandRepository(db *db.Db) *BrandRepository {
 	}
 }
 
-func (repo *BrandRepository) GetByID(id uint) (*Brand, error) {
+func (repo *BrandRepository) GetByID(ctx context.Context, id uint) (*Brand, error) {
 	var brand Brand
-	if err := repo.Db.First(&brand, id).Error; err != nil {
+	if err := repo.Db.WithContext(ctx).First(&brand, id).Error; err != nil {
 		return nil, err
 	}
 	return &brand, nil
 }
 
-func (repo *BrandRepository) GetAll() ([]*Brand, error) {
+func (repo *BrandRepository) GetAll(ctx context.Context) ([]*Brand, error) {
 	var brands []*Brand
-	if err := repo.Db.Find(&brands).Error; err != nil {
+	if err := repo.Db.WithContext(ctx).Find(&brands).Error; err != nil {
 		return nil, err
 	}
 	return brands, nil
 }
 
-func (repo *BrandRepository) Create(brand *Brand) (*Brand, error) {
-	result := repo.Db.Create(brand)
-	if result.Error != nil {
-		return nil, result.Error
+// Create, Update и Delete пишут запись в журнал аудита в той же транзакции.
+func (repo *BrandRepository) Create(ctx context.Context, brand *Brand) (*Brand, error) {
+	err := repo.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(brand).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntity, brand.ID, "create", brand)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return brand, nil
 }
 
-func (repo *BrandRepository) Update(brand *Brand) (*Brand, error) {
-	result := repo.Db.Model(&Brand{}).Where("id = ?", brand.ID).Updates(brand)
-	if result.Error != nil {
-		return nil, result.Error
+func (repo *BrandRepository) Update(ctx context.Context, brand *Brand) (*Brand, error) {
+	err := repo.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Brand{}).Where("id = ?", brand.ID).Updates(brand).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntity, brand.ID, "update", brand)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return brand, nil
 }
 
-func (repo *BrandRepository) Delete(id uint) error {
+// Delete удаляет бренд и обрабатывает его продукты по стратегии одной транзакцией.
+func (repo *BrandRepository) Delete(ctx context.Context, id uint, query DeleteBrandQuery, products interfaces.ProductDetacher) (int64, error) {
 	if id == 0 {
-		return errors.New("invalid brand ID")
+		return 0, errors.New("invalid brand ID")
 	}
-	result := repo.Db.Delete(&Brand{}, id)
-	if result.Error != nil {
-		return result.Error
+	var affected int64
+	err := repo.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if query.Strategy == interfaces.DeleteReassign {
+			if err := tx.Select("id").First(&Brand{}, query.TargetID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrReassignTarget
+				}
+				return err
+			}
+		}
+
+		var err error
+		if affected, err = interfaces.Detach(tx, products, interfaces.RefBrand, id, query.Strategy, query.TargetID); err != nil {
+			return err
+		}
+		if err := tx.Delete(&Brand{}, id).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntity, id, "delete", map[string]interface{}{
+			"strategy":          query.Strategy,
+			"target_id":         query.TargetID,
+			"affected_products": affected,
+		})
+	})
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	return affected, nil
 }
-
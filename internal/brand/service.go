@@ -1,34 +1,43 @@
 package brand
 
-import "errors"
+import (
+	"context"
+	"errors"
+
+	"github.com/ShopOnGO/product-service/pkg/interfaces"
+)
+
+var ErrReassignTarget = errors.New("target_id must reference another existing brand")
 
 type BrandService struct {
-	repo *BrandRepository
+	repo     *BrandRepository
+	products interfaces.ProductDetacher
 }
 
-func NewBrandService(repository *BrandRepository) *BrandService {
+func NewBrandService(repository *BrandRepository, products interfaces.ProductDetacher) *BrandService {
 	return &BrandService{
-		repo: repository,
+		repo:     repository,
+		products: products,
 	}
 }
 
-func (s *BrandService) GetBrandByID(id uint) (*Brand, error) {
-	return s.repo.GetByID(id)
+func (s *BrandService) GetBrandByID(ctx context.Context, id uint) (*Brand, error) {
+	return s.repo.GetByID(ctx, id)
 }
 
-func (s *BrandService) GetAllBrands() ([]*Brand, error) {
-	return s.repo.GetAll()
+func (s *BrandService) GetAllBrands(ctx context.Context) ([]*Brand, error) {
+	return s.repo.GetAll(ctx)
 }
 
-func (s *BrandService) CreateBrand(brand *Brand) (*Brand, error) {
-	return s.repo.Create(brand)
+func (s *BrandService) CreateBrand(ctx context.Context, brand *Brand) (*Brand, error) {
+	return s.repo.Create(ctx, brand)
 }
 
-func (s *BrandService) UpdateBrand(brand *Brand) (*Brand, error) {
+func (s *BrandService) UpdateBrand(ctx context.Context, brand *Brand) (*Brand, error) {
 	if brand.ID == 0 {
 		return nil, errors.New("invalid brand ID")
 	}
-	newBrand, err := s.repo.Update(brand)
+	newBrand, err := s.repo.Update(ctx, brand)
 	if err != nil {
 		return nil, err
 	}
@@ -36,10 +45,14 @@ func (s *BrandService) UpdateBrand(brand *Brand) (*Brand, error) {
 	return newBrand, nil
 }
 
-func (s *BrandService) DeleteBrand(id uint) error {
-	_, err := s.repo.GetByID(id)
+// DeleteBrand удаляет бренд, применяя стратегию к его продуктам, и возвращает число затронутых продуктов.
+func (s *BrandService) DeleteBrand(ctx context.Context, id uint, query DeleteBrandQuery) (int64, error) {
+	_, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	if query.Strategy == interfaces.DeleteReassign && (query.TargetID == 0 || query.TargetID == id) {
+		return 0, ErrReassignTarget
 	}
-	return s.repo.Delete(id)
+	return s.repo.Delete(ctx, id, query, s.products)
 }
@@ -0,0 +1,204 @@
+package campaign
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ShopOnGO/product-service/internal/auth"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CampaignHandlerDeps struct {
+	CampaignSvc *CampaignService
+	Auth        gin.HandlerFunc // проверка JWT
+}
+
+type CampaignHandler struct {
+	campaignSvc *CampaignService
+}
+
+func NewCampaignHandler(router *gin.Engine, deps CampaignHandlerDeps) *CampaignHandler {
+	handler := &CampaignHandler{
+		campaignSvc: deps.CampaignSvc,
+	}
+
+	campaignGroup := router.Group("/product-service/campaigns", deps.Auth, auth.RequireRoles(auth.RoleCatalogManager))
+	{
+		campaignGroup.GET("", handler.ListCampaigns)
+		campaignGroup.POST("", handler.CreateCampaign)
+		campaignGroup.GET("/:id", handler.GetCampaign)
+		campaignGroup.PUT("/:id", handler.UpdateCampaign)
+		campaignGroup.DELETE("/:id", handler.DeleteCampaign)
+	}
+
+	return handler
+}
+
+// ListCampaigns godoc
+// @Summary Список акций
+// @Description Скидочные акции с фильтром по статусу и цели, новые первыми
+// @Tags campaigns
+// @Produce json
+// @Param status query string false "Статус: disabled, scheduled, running, ended"
+// @Param target_type query string false "Тип цели: variant, product, brand, category"
+// @Param target_id query int false "ID цели (вместе с target_type)"
+// @Param page query int false "Номер страницы"
+// @Param page_size query int false "Размер страницы (до 100)"
+// @Success 200 {object} CampaignPage
+// @Failure 400 {object} gin.H "Неверные параметры"
+// @Failure 401 {object} gin.H "Требуется авторизация"
+// @Failure 403 {object} gin.H "Только admin и catalog-manager"
+// @Failure 500 {object} gin.H "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /campaigns [get]
+func (h *CampaignHandler) ListCampaigns(c *gin.Context) {
+	var q CampaignListQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	page, err := h.campaignSvc.ListCampaigns(c.Request.Context(), q)
+	if err != nil {
+		writeCampaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// CreateCampaign godoc
+// @Summary Создать акцию
+// @Description Создаёт скидку в процентах или фиксированной суммой на варианты, продукты, бренды или категории
+// @Description (с подкатегориями) на период [starts_at, ends_at). Акции применяются по убыванию priority;
+// @Description несуммируемая акция с наибольшим приоритетом исключает остальные
+// @Tags campaigns
+// @Accept json
+// @Produce json
+// @Param campaign body CreateCampaignPayload true "Акция"
+// @Success 201 {object} CampaignView
+// @Failure 400 {object} gin.H "Неверный запрос"
+// @Failure 401 {object} gin.H "Требуется авторизация"
+// @Failure 403 {object} gin.H "Только admin и catalog-manager"
+// @Failure 500 {object} gin.H "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /campaigns [post]
+func (h *CampaignHandler) CreateCampaign(c *gin.Context) {
+	var payload CreateCampaignPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	campaign, err := h.campaignSvc.CreateCampaign(c.Request.Context(), payload)
+	if err != nil {
+		writeCampaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, campaign)
+}
+
+// GetCampaign godoc
+// @Summary Получить акцию
+// @Tags campaigns
+// @Produce json
+// @Param id path int true "ID акции"
+// @Success 200 {object} CampaignView
+// @Failure 400 {object} gin.H "Неверный ID"
+// @Failure 401 {object} gin.H "Требуется авторизация"
+// @Failure 403 {object} gin.H "Только admin и catalog-manager"
+// @Failure 404 {object} gin.H "Акция не найдена"
+// @Failure 500 {object} gin.H "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /campaigns/{id} [get]
+func (h *CampaignHandler) GetCampaign(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid campaign ID"})
+		return
+	}
+
+	campaign, err := h.campaignSvc.GetCampaign(c.Request.Context(), uint(id))
+	if err != nil {
+		writeCampaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, campaign)
+}
+
+// UpdateCampaign godoc
+// @Summary Изменить акцию
+// @Description Меняет заданные поля; targets заменяют цели целиком. Цены вариантов пересчитываются сразу
+// @Tags campaigns
+// @Accept json
+// @Produce json
+// @Param id path int true "ID акции"
+// @Param campaign body UpdateCampaignPayload true "Изменяемые поля"
+// @Success 200 {object} CampaignView
+// @Failure 400 {object} gin.H "Неверный запрос"
+// @Failure 401 {object} gin.H "Требуется авторизация"
+// @Failure 403 {object} gin.H "Только admin и catalog-manager"
+// @Failure 404 {object} gin.H "Акция не найдена"
+// @Failure 500 {object} gin.H "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /campaigns/{id} [put]
+func (h *CampaignHandler) UpdateCampaign(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid campaign ID"})
+		return
+	}
+
+	var payload UpdateCampaignPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	campaign, err := h.campaignSvc.UpdateCampaign(c.Request.Context(), uint(id), payload)
+	if err != nil {
+		writeCampaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, campaign)
+}
+
+// DeleteCampaign godoc
+// @Summary Удалить акцию
+// @Description Удаляет акцию; если она действовала, варианты сразу возвращаются к ценам без неё
+// @Tags campaigns
+// @Param id path int true "ID акции"
+// @Success 200 {object} gin.H "Акция удалена"
+// @Failure 400 {object} gin.H "Неверный ID"
+// @Failure 401 {object} gin.H "Требуется авторизация"
+// @Failure 403 {object} gin.H "Только admin и catalog-manager"
+// @Failure 404 {object} gin.H "Акция не найдена"
+// @Failure 500 {object} gin.H "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /campaigns/{id} [delete]
+func (h *CampaignHandler) DeleteCampaign(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid campaign ID"})
+		return
+	}
+
+	if err := h.campaignSvc.DeleteCampaign(c.Request.Context(), uint(id)); err != nil {
+		writeCampaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "campaign deleted"})
+}
+
+// writeCampaignError переводит ошибки акций в HTTP-статусы.
+func writeCampaignError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "campaign not found"})
+	case errors.Is(err, ErrInvalidCampaign):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,80 @@
+package campaign
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Kind — способ расчёта скидки акции.
+type Kind string
+
+const (
+	KindPercent Kind = "percent" // процент от цены после предыдущих акций
+	KindFixed   Kind = "fixed"   // фиксированная сумма
+)
+
+// TargetType — на что направлена акция.
+type TargetType string
+
+const (
+	TargetVariant  TargetType = "variant"
+	TargetProduct  TargetType = "product"
+	TargetBrand    TargetType = "brand"
+	TargetCategory TargetType = "category" // вместе с подкатегориями
+)
+
+// Статусы акции, видимые в API; хранится только IsActive, остальное следует из времени.
+const (
+	StatusDisabled  = "disabled"
+	StatusScheduled = "scheduled"
+	StatusRunning   = "running"
+	StatusEnded     = "ended"
+)
+
+// Campaign — скидочная акция, действующая в окне [StartsAt, EndsAt).
+// Скидки действующих акций хранятся у вариантов в CampaignDiscount; их пересчитывает
+// планировщик на границах окна и сам сервис при изменении акции.
+type Campaign struct {
+	gorm.Model  `swaggerignore:"true"`
+	Name        string           `gorm:"type:varchar(255);not null" json:"name"`
+	Description string           `gorm:"type:text" json:"description,omitempty"`
+	Kind        Kind             `gorm:"type:varchar(20);not null" json:"kind"`
+	Value       decimal.Decimal  `gorm:"type:decimal(8,2);not null" json:"value"`
+	StartsAt    time.Time        `gorm:"not null;index" json:"starts_at"`
+	EndsAt      time.Time        `gorm:"not null;index" json:"ends_at"`
+	Priority    int              `gorm:"not null;default:0" json:"priority"`      // старшие применяются первыми
+	Stackable   bool             `gorm:"not null;default:false" json:"stackable"` // суммируется ли с другими акциями
+	IsActive    bool             `gorm:"not null" json:"is_active"`
+	Applied     bool             `gorm:"not null;default:false" json:"applied"` // учтена ли акция в ценах вариантов
+	Targets     []CampaignTarget `gorm:"foreignKey:CampaignID" json:"targets"`
+}
+
+// CampaignTarget — вариант, продукт, бренд или категория, на которые действует акция.
+// Цели заменяются целиком, поэтому без мягкого удаления.
+type CampaignTarget struct {
+	ID         uint       `gorm:"primaryKey" json:"-"`
+	CampaignID uint       `gorm:"not null;index" json:"-"`
+	Type       TargetType `gorm:"type:varchar(20);not null;index:idx_campaign_targets_target" json:"type"`
+	TargetID   uint       `gorm:"not null;index:idx_campaign_targets_target" json:"id"`
+}
+
+// Live сообщает, действует ли акция в момент now.
+func (c *Campaign) Live(now time.Time) bool {
+	return c.IsActive && !now.Before(c.StartsAt) && now.Before(c.EndsAt)
+}
+
+// Status возвращает статус акции в момент now.
+func (c *Campaign) Status(now time.Time) string {
+	switch {
+	case !c.IsActive:
+		return StatusDisabled
+	case now.Before(c.StartsAt):
+		return StatusScheduled
+	case now.Before(c.EndsAt):
+		return StatusRunning
+	default:
+		return StatusEnded
+	}
+}
@@ -0,0 +1,60 @@
+package campaign
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type TargetInput struct {
+	Type TargetType `json:"type" binding:"required,oneof=variant product brand category"`
+	ID   uint       `json:"id" binding:"required"`
+}
+
+type CreateCampaignPayload struct {
+	Name        string          `json:"name" binding:"required,max=255"`
+	Description string          `json:"description"`
+	Kind        Kind            `json:"kind" binding:"required,oneof=percent fixed"`
+	Value       decimal.Decimal `json:"value"`
+	StartsAt    time.Time       `json:"starts_at" binding:"required"`
+	EndsAt      time.Time       `json:"ends_at" binding:"required"`
+	Priority    int             `json:"priority"`
+	Stackable   bool            `json:"stackable"`
+	IsActive    *bool           `json:"is_active"` // по умолчанию true
+	Targets     []TargetInput   `json:"targets" binding:"required,min=1,dive"`
+}
+
+// UpdateCampaignPayload — незаданные поля не меняются; targets заменяют цели целиком.
+type UpdateCampaignPayload struct {
+	Name        *string          `json:"name" binding:"omitempty,max=255"`
+	Description *string          `json:"description"`
+	Kind        *Kind            `json:"kind" binding:"omitempty,oneof=percent fixed"`
+	Value       *decimal.Decimal `json:"value"`
+	StartsAt    *time.Time       `json:"starts_at"`
+	EndsAt      *time.Time       `json:"ends_at"`
+	Priority    *int             `json:"priority"`
+	Stackable   *bool            `json:"stackable"`
+	IsActive    *bool            `json:"is_active"`
+	Targets     *[]TargetInput   `json:"targets"`
+}
+
+// CampaignView — акция со статусом на момент ответа.
+type CampaignView struct {
+	Campaign
+	Status string `json:"status"`
+}
+
+type CampaignListQuery struct {
+	Status     string     `form:"status"` // disabled, scheduled, running, ended
+	TargetType TargetType `form:"target_type"`
+	TargetID   uint       `form:"target_id"`
+	Page       int        `form:"page"`
+	PageSize   int        `form:"page_size"`
+}
+
+type CampaignPage struct {
+	Items    []CampaignView `json:"items"`
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
@@ -0,0 +1,66 @@
+package campaign
+
+import (
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// BasePrice — цена, от которой считаются акции: цена варианта за вычетом его собственной скидки.
+func BasePrice(price, discount decimal.Decimal) decimal.Decimal {
+	base := price.Sub(discount)
+	if base.IsNegative() {
+		return decimal.Zero
+	}
+	return base
+}
+
+// EffectivePrice — итоговая цена варианта со своей скидкой и скидкой акций, не ниже нуля.
+func EffectivePrice(price, discount, campaignDiscount decimal.Decimal) decimal.Decimal {
+	effective := BasePrice(price, discount).Sub(campaignDiscount)
+	if effective.IsNegative() {
+		return decimal.Zero
+	}
+	return effective
+}
+
+// Apply считает суммарную скидку акций для базовой цены base.
+// Акции перебираются по убыванию приоритета (при равном — старшая по ID первой).
+// Первая акция применяется всегда, и если она не суммируется, остальные не применяются;
+// после неё применяются только суммируемые акции. Процент берётся от цены после
+// предыдущих акций, фиксированная сумма вычитается; скидка не превышает base.
+func Apply(base decimal.Decimal, campaigns []Campaign) decimal.Decimal {
+	ordered := make([]Campaign, len(campaigns))
+	copy(ordered, campaigns)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Priority != ordered[j].Priority {
+			return ordered[i].Priority > ordered[j].Priority
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	price := base
+	for i, c := range ordered {
+		if i > 0 && !c.Stackable {
+			continue
+		}
+		price = price.Sub(c.amount(price))
+		if !price.IsPositive() {
+			return base
+		}
+		if i == 0 && !c.Stackable {
+			break
+		}
+	}
+	return base.Sub(price)
+}
+
+// amount — скидка акции для цены price, округлённая до копеек.
+func (c *Campaign) amount(price decimal.Decimal) decimal.Decimal {
+	if c.Kind == KindPercent {
+		return price.Mul(c.Value).Div(hundred).Round(2)
+	}
+	return c.Value
+}
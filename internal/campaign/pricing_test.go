@@ -0,0 +1,150 @@
+package campaign
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func testCampaign(id uint, priority int, stackable bool, kind Kind, value string) Campaign {
+	return Campaign{
+		Model:     gorm.Model{ID: id},
+		Kind:      kind,
+		Value:     decimal.RequireFromString(value),
+		Priority:  priority,
+		Stackable: stackable,
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		campaigns []Campaign
+		want      string
+	}{
+		{
+			name: "без акций",
+			base: "1000",
+			want: "0",
+		},
+		{
+			name:      "процент",
+			base:      "1000",
+			campaigns: []Campaign{testCampaign(1, 0, false, KindPercent, "10")},
+			want:      "100",
+		},
+		{
+			name:      "фиксированная сумма",
+			base:      "1000",
+			campaigns: []Campaign{testCampaign(1, 0, false, KindFixed, "150")},
+			want:      "150",
+		},
+		{
+			name:      "процент округляется до копеек",
+			base:      "99.99",
+			campaigns: []Campaign{testCampaign(1, 0, false, KindPercent, "15")},
+			want:      "15",
+		},
+		{
+			name: "несуммируемая старшая акция отменяет остальные",
+			base: "1000",
+			campaigns: []Campaign{
+				testCampaign(2, 5, true, KindPercent, "10"),
+				testCampaign(1, 10, false, KindFixed, "100"),
+			},
+			want: "100",
+		},
+		{
+			name: "цепочка суммируемых: процент от цены после фиксированной",
+			base: "1000",
+			campaigns: []Campaign{
+				testCampaign(1, 10, true, KindFixed, "200"),
+				testCampaign(2, 5, true, KindPercent, "10"),
+			},
+			want: "280",
+		},
+		{
+			name: "цепочка суммируемых: фиксированная после процента",
+			base: "1000",
+			campaigns: []Campaign{
+				testCampaign(1, 5, true, KindFixed, "200"),
+				testCampaign(2, 10, true, KindPercent, "10"),
+			},
+			want: "300",
+		},
+		{
+			name: "несуммируемая младшая акция пропускается",
+			base: "1000",
+			campaigns: []Campaign{
+				testCampaign(1, 10, true, KindPercent, "10"),
+				testCampaign(2, 5, false, KindFixed, "100"),
+				testCampaign(3, 1, true, KindFixed, "50"),
+			},
+			want: "150",
+		},
+		{
+			name: "при равном приоритете первой идёт акция с меньшим ID",
+			base: "1000",
+			campaigns: []Campaign{
+				testCampaign(2, 5, false, KindFixed, "100"),
+				testCampaign(1, 5, false, KindPercent, "50"),
+			},
+			want: "500",
+		},
+		{
+			name:      "скидка равна базовой цене",
+			base:      "1000",
+			campaigns: []Campaign{testCampaign(1, 0, false, KindFixed, "1000")},
+			want:      "1000",
+		},
+		{
+			name: "скидка не превышает базовую цену",
+			base: "1000",
+			campaigns: []Campaign{
+				testCampaign(1, 10, true, KindFixed, "800"),
+				testCampaign(2, 5, true, KindFixed, "300"),
+			},
+			want: "1000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Apply(decimal.RequireFromString(tt.base), tt.campaigns)
+			if want := decimal.RequireFromString(tt.want); !got.Equal(want) {
+				t.Errorf("Apply() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestEffectivePrice(t *testing.T) {
+	tests := []struct {
+		name             string
+		price            string
+		discount         string
+		campaignDiscount string
+		want             string
+	}{
+		{"без скидок", "1000", "0", "0", "1000"},
+		{"своя скидка и акции", "1000", "100", "200", "700"},
+		{"своя скидка больше цены", "1000", "1200", "0", "0"},
+		{"скидка акций доходит до базовой цены", "1000", "100", "900", "0"},
+		{"скидка акций больше базовой цены", "1000", "100", "950", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EffectivePrice(
+				decimal.RequireFromString(tt.price),
+				decimal.RequireFromString(tt.discount),
+				decimal.RequireFromString(tt.campaignDiscount),
+			)
+			if want := decimal.RequireFromString(tt.want); !got.Equal(want) {
+				t.Errorf("EffectivePrice() = %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package campaign
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ShopOnGO/product-service/internal/audit"
+	"github.com/ShopOnGO/product-service/pkg/db"
+	"github.com/ShopOnGO/product-service/pkg/interfaces"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const auditEntity = "campaign"
+
+// liveCondition отбирает акции, действующие в момент ?, ?.
+const liveCondition = "is_active AND starts_at <= ? AND ends_at > ?"
+
+// categorySubtreeSQL выбирает категории и все их подкатегории; UNION отсекает циклы в старых данных.
+const categorySubtreeSQL = `WITH RECURSIVE subtree AS (
+	SELECT id FROM categories WHERE id IN ? AND deleted_at IS NULL
+	UNION
+	SELECT c.id FROM categories c JOIN subtree s ON c.parent_category_id = s.id WHERE c.deleted_at IS NULL
+) SELECT id FROM subtree`
+
+type CampaignRepository struct {
+	Db *db.Db
+}
+
+func NewCampaignRepository(db *db.Db) *CampaignRepository {
+	return &CampaignRepository{
+		Db: db,
+	}
+}
+
+func (repo *CampaignRepository) GetByID(ctx context.Context, id uint) (*Campaign, error) {
+	var campaign Campaign
+	if err := repo.Db.WithContext(ctx).Preload("Targets").First(&campaign, id).Error; err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// List возвращает страницу акций, новые первыми.
+func (repo *CampaignRepository) List(ctx context.Context, q CampaignListQuery, now time.Time, limit, offset int) ([]Campaign, int64, error) {
+	query := repo.Db.WithContext(ctx).Model(&Campaign{})
+	switch q.Status {
+	case StatusDisabled:
+		query = query.Where("NOT is_active")
+	case StatusScheduled:
+		query = query.Where("is_active AND starts_at > ?", now)
+	case StatusRunning:
+		query = query.Where(liveCondition, now, now)
+	case StatusEnded:
+		query = query.Where("is_active AND ends_at <= ?", now)
+	}
+	if q.TargetType != "" && q.TargetID != 0 {
+		query = query.Where("id IN (?)", repo.Db.Model(&CampaignTarget{}).
+			Select("campaign_id").
+			Where("type = ? AND target_id = ?", q.TargetType, q.TargetID))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var campaigns []Campaign
+	err := query.Preload("Targets").Order("id DESC").Limit(limit).Offset(offset).Find(&campaigns).Error
+	return campaigns, total, err
+}
+
+// Create, Update и Delete пересчитывают цены затронутых вариантов и пишут запись
+// в журнал аудита в той же транзакции, что и саму акцию.
+func (repo *CampaignRepository) Create(ctx context.Context, campaign *Campaign, variants interfaces.VariantRepricer) (*Campaign, error) {
+	err := repo.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(campaign).Error; err != nil {
+			return err
+		}
+		if err := apply(tx, campaign, nil, time.Now(), variants); err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntity, campaign.ID, "create", campaign)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// Update сохраняет акцию; при replaceTargets её цели заменяются на campaign.Targets.
+// Цены пересчитываются и у вариантов, на которые акция действовала до изменения.
+func (repo *CampaignRepository) Update(ctx context.Context, campaign *Campaign, replaceTargets bool, variants interfaces.VariantRepricer) (*Campaign, error) {
+	err := repo.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		previous, err := lockCampaign(tx, campaign.ID)
+		if err != nil {
+			return err
+		}
+		affected, err := appliedVariants(tx, previous)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&Campaign{}).Where("id = ?", campaign.ID).
+			Select("name", "description", "kind", "value", "starts_at", "ends_at", "priority", "stackable", "is_active").
+			Updates(campaign).Error; err != nil {
+			return err
+		}
+		if replaceTargets {
+			if err := replaceCampaignTargets(tx, campaign.ID, campaign.Targets); err != nil {
+				return err
+			}
+		}
+		if err := apply(tx, campaign, affected, time.Now(), variants); err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntity, campaign.ID, "update", campaign)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// Delete удаляет акцию и возвращает варианты к ценам без неё.
+func (repo *CampaignRepository) Delete(ctx context.Context, id uint, variants interfaces.VariantRepricer) error {
+	return repo.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		campaign, err := lockCampaign(tx, id)
+		if err != nil {
+			return err
+		}
+		affected, err := appliedVariants(tx, campaign)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("campaign_id = ?", id).Delete(&CampaignTarget{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&Campaign{}, id).Error; err != nil {
+			return err
+		}
+		if _, err := variants.Reprice(tx, affected, time.Now()); err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntity, id, "delete", map[string]interface{}{
+			"affected_variants": len(affected),
+		})
+	})
+}
+
+// DueIDs возвращает акции, которые начались или закончились, но ещё не учтены в ценах.
+func (repo *CampaignRepository) DueIDs(ctx context.Context, now time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := repo.Db.WithContext(ctx).Model(&Campaign{}).
+		Where("("+liveCondition+") <> applied", now, now).
+		Order("id").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Sync применяет или снимает акцию по состоянию на now под блокировкой строки акции:
+// параллельный планировщик или правка акции дождутся окончания. Возвращает false,
+// если акцию уже учли.
+func (repo *CampaignRepository) Sync(ctx context.Context, id uint, now time.Time, variants interfaces.VariantRepricer) (bool, error) {
+	synced := false
+	err := repo.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		campaign, err := lockCampaign(tx, id)
+		if err != nil {
+			return err
+		}
+		live := campaign.Live(now)
+		if live == campaign.Applied {
+			return nil
+		}
+		affected, err := matchVariants(tx, campaign.Targets, nil)
+		if err != nil {
+			return err
+		}
+		changed, err := variants.Reprice(tx, affected, now)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&Campaign{}).Where("id = ?", id).Update("applied", live).Error; err != nil {
+			return err
+		}
+		action := "revert"
+		if live {
+			action = "apply"
+		}
+		synced = true
+		return audit.Record(tx, auditEntity, id, action, map[string]interface{}{
+			"changed_variants": changed,
+		})
+	})
+	return synced, err
+}
+
+// Discounts считает скидку действующих акций для вариантов по их базовым ценам (см. BasePrice).
+// Вызывается в транзакции, меняющей цены вариантов.
+func Discounts(tx *gorm.DB, bases map[uint]decimal.Decimal, now time.Time) (map[uint]decimal.Decimal, error) {
+	discounts := make(map[uint]decimal.Decimal, len(bases))
+	if len(bases) == 0 {
+		return discounts, nil
+	}
+
+	var campaigns []Campaign
+	if err := tx.Preload("Targets").Where(liveCondition, now, now).Find(&campaigns).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(bases))
+	for id := range bases {
+		ids = append(ids, id)
+	}
+	matched := make(map[uint][]Campaign, len(bases))
+	for _, c := range campaigns {
+		variantIDs, err := matchVariants(tx, c.Targets, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range variantIDs {
+			matched[id] = append(matched[id], c)
+		}
+	}
+
+	for id, base := range bases {
+		discounts[id] = Apply(base, matched[id])
+	}
+	return discounts, nil
+}
+
+// matchVariants возвращает неудалённые варианты, на которые направлены targets.
+// restrict, если задан, ограничивает поиск этими вариантами.
+func matchVariants(tx *gorm.DB, targets []CampaignTarget, restrict []uint) ([]uint, error) {
+	byType := make(map[TargetType][]uint)
+	for _, t := range targets {
+		byType[t.Type] = append(byType[t.Type], t.TargetID)
+	}
+
+	var conditions []string
+	var args []interface{}
+	for _, m := range []struct {
+		target    TargetType
+		condition string
+	}{
+		{TargetVariant, "pv.id IN ?"},
+		{TargetProduct, "pv.product_id IN ?"},
+		{TargetBrand, "p.brand_id IN ?"},
+		{TargetCategory, "p.category_id IN (" + categorySubtreeSQL + ")"},
+	} {
+		if ids := byType[m.target]; len(ids) > 0 {
+			conditions = append(conditions, m.condition)
+			args = append(args, ids)
+		}
+	}
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+
+	query := tx.Table("product_variants pv").
+		Joins("JOIN products p ON p.id = pv.product_id AND p.deleted_at IS NULL").
+		Where("pv.deleted_at IS NULL").
+		Where("("+strings.Join(conditions, " OR ")+")", args...)
+	if restrict != nil {
+		query = query.Where("pv.id IN ?", restrict)
+	}
+
+	var ids []uint
+	err := query.Order("pv.id").Pluck("pv.id", &ids).Error
+	return ids, err
+}
+
+// apply пересчитывает цены вариантов из before и тех, на которые акция действует сейчас,
+// и запоминает, учтена ли акция в ценах.
+func apply(tx *gorm.DB, campaign *Campaign, before []uint, now time.Time, variants interfaces.VariantRepricer) error {
+	live := campaign.Live(now)
+	affected := before
+	if live {
+		current, err := matchVariants(tx, campaign.Targets, nil)
+		if err != nil {
+			return err
+		}
+		affected = mergeIDs(affected, current)
+	}
+	if _, err := variants.Reprice(tx, affected, now); err != nil {
+		return err
+	}
+	campaign.Applied = live
+	return tx.Model(&Campaign{}).Where("id = ?", campaign.ID).Update("applied", live).Error
+}
+
+// appliedVariants возвращает варианты, в ценах которых акция учтена сейчас.
+func appliedVariants(tx *gorm.DB, campaign *Campaign) ([]uint, error) {
+	if !campaign.Applied {
+		return nil, nil
+	}
+	return matchVariants(tx, campaign.Targets, nil)
+}
+
+func lockCampaign(tx *gorm.DB, id uint) (*Campaign, error) {
+	var campaign Campaign
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Targets").First(&campaign, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+func replaceCampaignTargets(tx *gorm.DB, campaignID uint, targets []CampaignTarget) error {
+	if err := tx.Where("campaign_id = ?", campaignID).Delete(&CampaignTarget{}).Error; err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	for i := range targets {
+		targets[i].ID = 0
+		targets[i].CampaignID = campaignID
+	}
+	return tx.Create(&targets).Error
+}
+
+func mergeIDs(a, b []uint) []uint {
+	seen := make(map[uint]struct{}, len(a)+len(b))
+	merged := make([]uint, 0, len(a)+len(b))
+	for _, ids := range [][]uint{a, b} {
+		for _, id := range ids {
+			if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				merged = append(merged, id)
+			}
+		}
+	}
+	return merged
+}
@@ -0,0 +1,262 @@
+package campaign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/product-service/pkg/interfaces"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	syncBatchSize   = 100
+)
+
+var ErrInvalidCampaign = errors.New("invalid campaign")
+
+type CampaignService struct {
+	repo     *CampaignRepository
+	variants interfaces.VariantRepricer
+}
+
+func NewCampaignService(repo *CampaignRepository, variants interfaces.VariantRepricer) *CampaignService {
+	return &CampaignService{
+		repo:     repo,
+		variants: variants,
+	}
+}
+
+func (s *CampaignService) CreateCampaign(ctx context.Context, payload CreateCampaignPayload) (*CampaignView, error) {
+	campaign := &Campaign{
+		Name:        strings.TrimSpace(payload.Name),
+		Description: payload.Description,
+		Kind:        payload.Kind,
+		Value:       payload.Value,
+		StartsAt:    payload.StartsAt,
+		EndsAt:      payload.EndsAt,
+		Priority:    payload.Priority,
+		Stackable:   payload.Stackable,
+		IsActive:    payload.IsActive == nil || *payload.IsActive,
+		Targets:     newTargets(payload.Targets),
+	}
+	if err := validateCampaign(campaign); err != nil {
+		return nil, err
+	}
+	created, err := s.repo.Create(ctx, campaign, s.variants)
+	if err != nil {
+		return nil, err
+	}
+	return newCampaignView(created, time.Now()), nil
+}
+
+func (s *CampaignService) GetCampaign(ctx context.Context, id uint) (*CampaignView, error) {
+	campaign, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return newCampaignView(campaign, time.Now()), nil
+}
+
+func (s *CampaignService) ListCampaigns(ctx context.Context, q CampaignListQuery) (*CampaignPage, error) {
+	switch q.Status {
+	case "", StatusDisabled, StatusScheduled, StatusRunning, StatusEnded:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidCampaign, q.Status)
+	}
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = defaultPageSize
+	}
+	if q.PageSize > maxPageSize {
+		q.PageSize = maxPageSize
+	}
+
+	now := time.Now()
+	campaigns, total, err := s.repo.List(ctx, q, now, q.PageSize, (q.Page-1)*q.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]CampaignView, 0, len(campaigns))
+	for i := range campaigns {
+		items = append(items, *newCampaignView(&campaigns[i], now))
+	}
+	return &CampaignPage{
+		Items:    items,
+		Total:    total,
+		Page:     q.Page,
+		PageSize: q.PageSize,
+	}, nil
+}
+
+func (s *CampaignService) UpdateCampaign(ctx context.Context, id uint, payload UpdateCampaignPayload) (*CampaignView, error) {
+	campaign, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload.Name != nil {
+		campaign.Name = strings.TrimSpace(*payload.Name)
+	}
+	if payload.Description != nil {
+		campaign.Description = *payload.Description
+	}
+	if payload.Kind != nil {
+		campaign.Kind = *payload.Kind
+	}
+	if payload.Value != nil {
+		campaign.Value = *payload.Value
+	}
+	if payload.StartsAt != nil {
+		campaign.StartsAt = *payload.StartsAt
+	}
+	if payload.EndsAt != nil {
+		campaign.EndsAt = *payload.EndsAt
+	}
+	if payload.Priority != nil {
+		campaign.Priority = *payload.Priority
+	}
+	if payload.Stackable != nil {
+		campaign.Stackable = *payload.Stackable
+	}
+	if payload.IsActive != nil {
+		campaign.IsActive = *payload.IsActive
+	}
+	if payload.Targets != nil {
+		campaign.Targets = newTargets(*payload.Targets)
+	}
+	if err := validateCampaign(campaign); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.Update(ctx, campaign, payload.Targets != nil, s.variants)
+	if err != nil {
+		return nil, err
+	}
+	return newCampaignView(updated, time.Now()), nil
+}
+
+// DeleteCampaign удаляет акцию; если она действовала, цены вариантов сразу пересчитываются.
+func (s *CampaignService) DeleteCampaign(ctx context.Context, id uint) error {
+	return s.repo.Delete(ctx, id, s.variants)
+}
+
+// SyncDue применяет начавшиеся и снимает закончившиеся акции и возвращает их количество.
+func (s *CampaignService) SyncDue(ctx context.Context, now time.Time) (int, error) {
+	ids, err := s.repo.DueIDs(ctx, now, syncBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	synced := 0
+	for _, id := range ids {
+		ok, err := s.repo.Sync(ctx, id, now, s.variants)
+		if err != nil {
+			return synced, fmt.Errorf("campaign %d: %w", id, err)
+		}
+		if ok {
+			synced++
+		}
+	}
+	return synced, nil
+}
+
+func newTargets(inputs []TargetInput) []CampaignTarget {
+	seen := make(map[TargetInput]struct{}, len(inputs))
+	targets := make([]CampaignTarget, 0, len(inputs))
+	for _, input := range inputs {
+		if _, ok := seen[input]; ok {
+			continue
+		}
+		seen[input] = struct{}{}
+		targets = append(targets, CampaignTarget{Type: input.Type, TargetID: input.ID})
+	}
+	return targets
+}
+
+func validateCampaign(c *Campaign) error {
+	if c.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCampaign)
+	}
+	switch c.Kind {
+	case KindPercent:
+		if c.Value.GreaterThan(decimal.NewFromInt(100)) {
+			return fmt.Errorf("%w: percent value must not exceed 100", ErrInvalidCampaign)
+		}
+	case KindFixed:
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidCampaign, c.Kind)
+	}
+	if !c.Value.IsPositive() {
+		return fmt.Errorf("%w: value must be greater than zero", ErrInvalidCampaign)
+	}
+	if !c.EndsAt.After(c.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidCampaign)
+	}
+	if len(c.Targets) == 0 {
+		return fmt.Errorf("%w: at least one target is required", ErrInvalidCampaign)
+	}
+	for _, t := range c.Targets {
+		switch t.Type {
+		case TargetVariant, TargetProduct, TargetBrand, TargetCategory:
+		default:
+			return fmt.Errorf("%w: unknown target type %q", ErrInvalidCampaign, t.Type)
+		}
+		if t.TargetID == 0 {
+			return fmt.Errorf("%w: target id is required", ErrInvalidCampaign)
+		}
+	}
+	return nil
+}
+
+func newCampaignView(c *Campaign, now time.Time) *CampaignView {
+	return &CampaignView{Campaign: *c, Status: c.Status(now)}
+}
+
+// CampaignScheduler периодически применяет начавшиеся и снимает закончившиеся акции.
+type CampaignScheduler struct {
+	svc      *CampaignService
+	interval time.Duration
+}
+
+func NewCampaignScheduler(svc *CampaignService, interval time.Duration) *CampaignScheduler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &CampaignScheduler{
+		svc:      svc,
+		interval: interval,
+	}
+}
+
+// Run работает до отмены контекста. Первый проход выполняется сразу: за время простоя
+// сервиса акции могли начаться или закончиться.
+func (sc *CampaignScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(sc.interval)
+	defer ticker.Stop()
+
+	for {
+		synced, err := sc.svc.SyncDue(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			logger.Errorf("Ошибка при применении акций: %v", err)
+		}
+		if synced > 0 {
+			logger.Infof("Применено и снято акций: %d", synced)
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Campaign scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package category
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
+	"github.com/ShopOnGO/product-service/internal/auth"
+	"github.com/ShopOnGO/product-service/pkg/interfaces"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -12,6 +15,7 @@ import (
 type CategoryHandlerDeps struct {
 	CategorySvc *CategoryService
 	Kafka       *kafkaService.KafkaService
+	Auth        gin.HandlerFunc // проверка JWT для изменяющих запросов
 }
 
 type CategoryHandler struct {
@@ -27,12 +31,16 @@ func NewCategoryHandler(router *gin.Engine, deps CategoryHandlerDeps) *CategoryH
 
 	categoryGroup := router.Group("/product-service/categories")
 	{
-		categoryGroup.POST("/", handler.CreateCategory)
+		categoryGroup.POST("/", deps.Auth, auth.RequireRoles(auth.RoleCatalogManager), handler.CreateCategory)
 		categoryGroup.GET("/featured", handler.GetFeaturedCategories)
 		categoryGroup.GET("/by-name", handler.GetCategoryByName)
+		categoryGroup.GET("/tree", handler.GetCategoryTree)
 		categoryGroup.GET("/:id", handler.GetCategoryByID)
-		categoryGroup.PUT("/:id", handler.UpdateCategory)
-		categoryGroup.DELETE("/:id", handler.DeleteCategory)
+		categoryGroup.GET("/:id/ancestors", handler.GetCategoryAncestors)
+		categoryGroup.GET("/:id/descendants", handler.GetCategoryDescendants)
+		categoryGroup.POST("/:id/move", deps.Auth, auth.RequireRoles(auth.RoleCatalogManager), handler.MoveCategory)
+		categoryGroup.PUT("/:id", deps.Auth, auth.RequireRoles(auth.RoleCatalogManager), handler.UpdateCategory)
+		categoryGroup.DELETE("/:id", deps.Auth, auth.RequireRoles(auth.RoleAdmin), handler.DeleteCategory)
 	}
 
 	return handler
@@ -48,6 +56,9 @@ func NewCategoryHandler(router *gin.Engine, deps CategoryHandlerDeps) *CategoryH
 // @Success 201 {object} Category
 // @Failure 400 {object} gin.H "Неверный запрос"
 // @Failure 500 {object} gin.H "Ошибка сервера"
+// @Failure 401 {object} gin.H "Требуется авторизация"
+// @Failure 403 {object} gin.H "Только admin и catalog-manager"
+// @Security ApiKeyAuth
 // @Router /categories/ [post]
 func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 	var payload CategoryPayload
@@ -63,7 +74,7 @@ func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 		ParentCategoryID: payload.ParentCategoryID,
 	}
 
-	created, err := h.categorySvc.CreateCategory(category)
+	created, err := h.categorySvc.CreateCategory(c.Request.Context(), category)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -101,7 +112,7 @@ func (h *CategoryHandler) GetFeaturedCategories(c *gin.Context) {
 		}
 	}
 
-	categories, err := h.categorySvc.GetFeaturedCategories(amount)
+	categories, err := h.categorySvc.GetFeaturedCategories(c.Request.Context(), amount)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -127,7 +138,7 @@ func (h *CategoryHandler) GetCategoryByID(c *gin.Context) {
 		return
 	}
 
-	category, err := h.categorySvc.GetCategoryByID(uint(id))
+	category, err := h.categorySvc.GetCategoryByID(c.Request.Context(), uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -154,7 +165,7 @@ func (h *CategoryHandler) GetCategoryByName(c *gin.Context) {
 		return
 	}
 
-	category, err := h.categorySvc.GetCategoryByName(name)
+	category, err := h.categorySvc.GetCategoryByName(c.Request.Context(), name)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -173,7 +184,12 @@ func (h *CategoryHandler) GetCategoryByName(c *gin.Context) {
 // @Param category body CategoryPayload true "Обновленные данные категории"
 // @Success 200 {object} Category
 // @Failure 400 {object} gin.H "Неверный запрос"
+// @Failure 404 {object} gin.H "Категория или родитель не найдены"
+// @Failure 409 {object} gin.H "Родитель лежит в поддереве категории"
 // @Failure 500 {object} gin.H "Ошибка сервера"
+// @Failure 401 {object} gin.H "Требуется авторизация"
+// @Failure 403 {object} gin.H "Только admin и catalog-manager"
+// @Security ApiKeyAuth
 // @Router /categories/{id} [put]
 func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -196,9 +212,9 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 		ParentCategoryID: payload.ParentCategoryID,
 	}
 
-	updated, err := h.categorySvc.UpdateCategory(category)
+	updated, err := h.categorySvc.UpdateCategory(c.Request.Context(), category)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeCategoryError(c, err)
 		return
 	}
 
@@ -218,14 +234,22 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 
 // DeleteCategory godoc
 // @Summary Удалить категорию
-// @Description Удаляет категорию по её ID
+// @Description Удаляет категорию без подкатегорий. Если в категории есть продукты, нужна стратегия:
+// @Description reassign — перенести в target_id, deactivate — снять с продажи, force — удалить вместе с категорией
 // @Tags categories
 // @Accept json
 // @Produce json
 // @Param id path int true "ID категории"
-// @Success 200 {object} gin.H "Категория удалена"
-// @Failure 400 {object} gin.H "Неверный ID"
+// @Param strategy query string false "Стратегия для продуктов" Enums(reassign, deactivate, force)
+// @Param target_id query int false "Категория для стратегии reassign"
+// @Success 200 {object} gin.H "Категория удалена, affected_products — число затронутых продуктов"
+// @Failure 400 {object} gin.H "Неверный ID, стратегия или target_id"
+// @Failure 404 {object} gin.H "Категория не найдена"
+// @Failure 409 {object} gin.H "Есть подкатегории, продукты без выбранной стратегии или атрибуты вариантов не подходят целевой категории"
 // @Failure 500 {object} gin.H "Ошибка сервера"
+// @Failure 401 {object} gin.H "Требуется авторизация"
+// @Failure 403 {object} gin.H "Только admin"
+// @Security ApiKeyAuth
 // @Router /categories/{id} [delete]
 func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -234,8 +258,15 @@ func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 		return
 	}
 
-	if err := h.categorySvc.DeleteCategory(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var query DeleteCategoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	affected, err := h.categorySvc.DeleteCategory(c.Request.Context(), uint(id), query)
+	if err != nil {
+		writeCategoryError(c, err)
 		return
 	}
 
@@ -249,7 +280,142 @@ func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 	// 		"message":    fmt.Sprintf("Категория '%v' была удалена.", id),
 	// 	},
 	// )
-	c.JSON(http.StatusOK, gin.H{"message": "category deleted"})
+	c.JSON(http.StatusOK, gin.H{"message": "category deleted", "affected_products": affected})
+}
+
+// GetCategoryTree godoc
+// @Summary Дерево категорий
+// @Description Возвращает категории с вложенными подкатегориями на любую глубину
+// @Tags categories
+// @Produce json
+// @Param root_id query int false "ID категории, поддерево которой нужно (по умолчанию всё дерево)"
+// @Param max_depth query int false "Максимум уровней, 1 — только корни (по умолчанию без ограничения)"
+// @Success 200 {array} CategoryNode
+// @Failure 400 {object} gin.H "Неверные параметры"
+// @Failure 404 {object} gin.H "Категория не найдена"
+// @Failure 500 {object} gin.H "Ошибка сервера"
+// @Router /categories/tree [get]
+func (h *CategoryHandler) GetCategoryTree(c *gin.Context) {
+	var query struct {
+		RootID   uint `form:"root_id"`
+		MaxDepth int  `form:"max_depth" binding:"min=0"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	tree, err := h.categorySvc.GetTree(c.Request.Context(), query.RootID, query.MaxDepth)
+	if err != nil {
+		writeCategoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tree)
+}
+
+// GetCategoryAncestors godoc
+// @Summary Предки категории
+// @Description Хлебные крошки: цепочка категорий от корня до родителя
+// @Tags categories
+// @Produce json
+// @Param id path int true "ID категории"
+// @Success 200 {array} CategoryNode
+// @Failure 400 {object} gin.H "Неверный ID"
+// @Failure 404 {object} gin.H "Категория не найдена"
+// @Failure 500 {object} gin.H "Ошибка сервера"
+// @Router /categories/{id}/ancestors [get]
+func (h *CategoryHandler) GetCategoryAncestors(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	ancestors, err := h.categorySvc.GetAncestors(c.Request.Context(), uint(id))
+	if err != nil {
+		writeCategoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, ancestors)
+}
+
+// GetCategoryDescendants godoc
+// @Summary Подкатегории на всех уровнях
+// @Description Плоский список всех подкатегорий; depth — расстояние от категории
+// @Tags categories
+// @Produce json
+// @Param id path int true "ID категории"
+// @Success 200 {array} CategoryNode
+// @Failure 400 {object} gin.H "Неверный ID"
+// @Failure 404 {object} gin.H "Категория не найдена"
+// @Failure 500 {object} gin.H "Ошибка сервера"
+// @Router /categories/{id}/descendants [get]
+func (h *CategoryHandler) GetCategoryDescendants(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	descendants, err := h.categorySvc.GetDescendants(c.Request.Context(), uint(id))
+	if err != nil {
+		writeCategoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, descendants)
+}
+
+// MoveCategory godoc
+// @Summary Перенести категорию
+// @Description Переносит категорию вместе со всеми подкатегориями под нового родителя или в корень
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path int true "ID категории"
+// @Param move body MoveCategoryPayload true "Новый родитель (null — корень)"
+// @Success 200 {object} Category
+// @Failure 400 {object} gin.H "Неверный запрос"
+// @Failure 401 {object} gin.H "Требуется авторизация"
+// @Failure 403 {object} gin.H "Только admin и catalog-manager"
+// @Failure 404 {object} gin.H "Категория или родитель не найдены"
+// @Failure 409 {object} gin.H "Родитель лежит в поддереве категории"
+// @Failure 500 {object} gin.H "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /categories/{id}/move [post]
+func (h *CategoryHandler) MoveCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	var payload MoveCategoryPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	moved, err := h.categorySvc.MoveCategory(c.Request.Context(), uint(id), payload.ParentCategoryID)
+	if err != nil {
+		writeCategoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, moved)
+}
+
+// writeCategoryError переводит ошибки дерева и удаления категорий в HTTP-статусы.
+func writeCategoryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, ErrParentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrCategoryCycle), errors.Is(err, ErrHasSubcategories), errors.Is(err, interfaces.ErrHasProducts),
+		errors.Is(err, interfaces.ErrReassignAttributes):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, interfaces.ErrUnknownStrategy), errors.Is(err, ErrReassignTarget):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
 
 // func (h *CategoryHandler) sendNotification(
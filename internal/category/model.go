@@ -8,6 +8,6 @@ type Category struct {
 	Description      string     `gorm:"type:text" json:"description"`
 	ImageURL         string     `gorm:"type:varchar(255)" json:"image_url"` // Ссылка на изображение категории
 	ParentCategoryID *uint      `gorm:"index"`                              // Внешний ключ может быть NULL
-	ParentCategory   *Category  `gorm:"foreignKey:ParentCategoryID;constraint:OnDelete:CASCADE"`
-	SubCategories    []Category `gorm:"foreignKey:ParentCategoryID"` // Связь для подкатегорий
+	ParentCategory   *Category  `gorm:"foreignKey:ParentCategoryID;constraint:OnDelete:RESTRICT"`
+	SubCategories    []Category `gorm:"foreignKey:ParentCategoryID;constraint:OnDelete:RESTRICT"` // Связь для подкатегорий; внешний ключ создаётся по ней
 }
@@ -1,8 +1,43 @@
 package category
 
+import "github.com/ShopOnGO/product-service/pkg/interfaces"
+
 type CategoryPayload struct {
 	Name             string `json:"name" binding:"required"`
 	Description      string `json:"description"`
 	ImageURL         string `json:"image_url"`
 	ParentCategoryID *uint  `json:"parent_category_id"`
-}
\ No newline at end of file
+}
+
+// CategoryNode — узел дерева категорий.
+type CategoryNode struct {
+	ID               uint            `json:"id"`
+	Name             string          `json:"name"`
+	Description      string          `json:"description"`
+	ImageURL         string          `json:"image_url"`
+	ParentCategoryID *uint           `json:"parent_category_id"`
+	Depth            int             `json:"depth"`
+	Children         []*CategoryNode `json:"children,omitempty"`
+}
+
+// DeleteCategoryQuery — что сделать с продуктами категории при удалении.
+type DeleteCategoryQuery struct {
+	Strategy interfaces.DeleteStrategy `form:"strategy"`  // пусто — отказать, если продукты есть
+	TargetID uint                      `form:"target_id"` // категория для стратегии reassign
+}
+
+// MoveCategoryPayload — новый родитель для переноса поддерева.
+type MoveCategoryPayload struct {
+	ParentCategoryID *uint `json:"parent_category_id"` // null — сделать категорию корневой
+}
+
+func newCategoryNode(c *Category, depth int) *CategoryNode {
+	return &CategoryNode{
+		ID:               c.ID,
+		Name:             c.Name,
+		Description:      c.Description,
+		ImageURL:         c.ImageURL,
+		ParentCategoryID: c.ParentCategoryID,
+		Depth:            depth,
+	}
+}
@@ -1,11 +1,18 @@
 package category
 
 import (
+	"context"
 	"errors"
+	"time"
 
+	"github.com/ShopOnGO/product-service/internal/audit"
 	"github.com/ShopOnGO/product-service/pkg/db"
+	"github.com/ShopOnGO/product-service/pkg/interfaces"
+	"gorm.io/gorm"
 )
 
+const auditEntity = "category"
+
 type CategoryRepository struct {
 	Db *db.Db
 }
@@ -16,20 +23,26 @@ func NewCategoryRepository(db *db.Db) *CategoryRepository {
 	}
 }
 
-func (repo *CategoryRepository) Create(category *Category) (*Category, error) {
-	result := repo.Db.Create(category)
-	if result.Error != nil {
-		return nil, result.Error
+// Create, Update и Delete пишут запись в журнал аудита в той же транзакции.
+func (repo *CategoryRepository) Create(ctx context.Context, category *Category) (*Category, error) {
+	err := repo.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(category).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntity, category.ID, "create", category)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return category, nil
 }
 
-func (repo *CategoryRepository) GetFeaturedCategories(amount int) ([]Category, error) {
+func (repo *CategoryRepository) GetFeaturedCategories(ctx context.Context, amount int) ([]Category, error) {
 	if amount > 20 {
 		amount = 20
 	}
 	var categories []Category
-	query := repo.Db.DB.Preload("SubCategories")
+	query := repo.Db.WithContext(ctx).Preload("SubCategories")
 
 	if amount > 0 {
 		query = query.Limit(amount)
@@ -43,43 +56,236 @@ func (repo *CategoryRepository) GetFeaturedCategories(amount int) ([]Category, e
 	return categories, nil
 }
 
-func (repo *CategoryRepository) GetByName(name string) (*Category, error) {
+func (repo *CategoryRepository) GetByName(ctx context.Context, name string) (*Category, error) {
 	var category Category
-	result := repo.Db.Preload("SubCategories").First(&category, "name = ?", name)
+	result := repo.Db.WithContext(ctx).Preload("SubCategories").First(&category, "name = ?", name)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return &category, nil
 }
 
-func (repo *CategoryRepository) GetByID(id uint) (*Category, error) {
+func (repo *CategoryRepository) GetByID(ctx context.Context, id uint) (*Category, error) {
 	if id == 0 {
 		return nil, errors.New("invalid category ID")
 	}
 	var category Category
-	result := repo.Db.Preload("SubCategories").First(&category, id)
+	result := repo.Db.WithContext(ctx).Preload("SubCategories").First(&category, id)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return &category, nil
 }
 
-func (repo *CategoryRepository) Update(category *Category) (*Category, error) {
-	result := repo.Db.Model(&Category{}).Where("id = ?", category.ID).Updates(category)
-	if result.Error != nil {
-		return nil, result.Error
+func (repo *CategoryRepository) Update(ctx context.Context, category *Category, variants interfaces.VariantRepricer) (*Category, error) {
+	err := repo.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing Category
+		if category.ParentCategoryID != nil {
+			if err := lockTree(tx); err != nil {
+				return err
+			}
+			if err := tx.Select("id", "parent_category_id").First(&existing, category.ID).Error; err != nil {
+				return err
+			}
+			if err := checkParent(tx, category.ID, category.ParentCategoryID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&Category{}).Where("id = ?", category.ID).Updates(category).Error; err != nil {
+			return err
+		}
+		// Смена родителя переносит поддерево, как Move: цены его вариантов пересчитываются
+		if category.ParentCategoryID != nil &&
+			(existing.ParentCategoryID == nil || *existing.ParentCategoryID != *category.ParentCategoryID) {
+			variantIDs, err := subtreeVariantIDs(tx, category.ID)
+			if err != nil {
+				return err
+			}
+			if _, err := variants.Reprice(tx, variantIDs, time.Now()); err != nil {
+				return err
+			}
+		}
+		return audit.Record(tx, auditEntity, category.ID, "update", category)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return category, nil
 }
 
-func (repo *CategoryRepository) Delete(id uint) error {
+// Delete удаляет категорию и обрабатывает её продукты по стратегии одной транзакцией.
+// Категория с подкатегориями не удаляется: их сначала нужно перенести или удалить.
+func (repo *CategoryRepository) Delete(ctx context.Context, id uint, query DeleteCategoryQuery, products interfaces.ProductDetacher) (int64, error) {
 	if id == 0 {
-		return errors.New("invalid  ID")
+		return 0, errors.New("invalid  ID")
 	}
-	result := repo.Db.Delete(&Category{}, id)
-	if result.Error != nil {
-		return result.Error
+	var affected int64
+	err := repo.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockTree(tx); err != nil {
+			return err
+		}
+
+		var subCategories int64
+		if err := tx.Model(&Category{}).Where("parent_category_id = ?", id).Count(&subCategories).Error; err != nil {
+			return err
+		}
+		if subCategories > 0 {
+			return ErrHasSubcategories
+		}
+
+		if query.Strategy == interfaces.DeleteReassign {
+			if err := tx.Select("id").First(&Category{}, query.TargetID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrReassignTarget
+				}
+				return err
+			}
+		}
+
+		var err error
+		if affected, err = interfaces.Detach(tx, products, interfaces.RefCategory, id, query.Strategy, query.TargetID); err != nil {
+			return err
+		}
+		if err := tx.Delete(&Category{}, id).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntity, id, "delete", map[string]interface{}{
+			"strategy":          query.Strategy,
+			"target_id":         query.TargetID,
+			"affected_products": affected,
+		})
+	})
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	return affected, nil
+}
+
+// maxTreeDepth ограничивает рекурсивные запросы, чтобы цикл в старых данных не зациклил CTE.
+const maxTreeDepth = 64
+
+// treeLockKey — ключ advisory-блокировки для операций, меняющих форму дерева:
+// две встречные перестановки иначе могли бы вместе создать цикл.
+const treeLockKey = 0x636174 // "cat"
+
+const ancestorsSQL = `WITH RECURSIVE ancestors AS (
+	SELECT id, parent_category_id, 0 AS depth FROM categories WHERE id = ? AND deleted_at IS NULL
+	UNION ALL
+	SELECT c.id, c.parent_category_id, a.depth + 1 FROM categories c
+	JOIN ancestors a ON c.id = a.parent_category_id
+	WHERE c.deleted_at IS NULL AND a.depth < ?
+) SELECT c.*, a.depth FROM categories c JOIN ancestors a ON a.id = c.id WHERE a.depth > 0 ORDER BY a.depth DESC`
+
+const descendantsSQL = `WITH RECURSIVE subtree AS (
+	SELECT id, 0 AS depth FROM categories WHERE id = ? AND deleted_at IS NULL
+	UNION ALL
+	SELECT c.id, s.depth + 1 FROM categories c
+	JOIN subtree s ON c.parent_category_id = s.id
+	WHERE c.deleted_at IS NULL AND s.depth < ?
+) SELECT c.*, s.depth FROM categories c JOIN subtree s ON s.id = c.id WHERE s.depth > 0 ORDER BY s.depth, c.name`
+
+// categoryWithDepth — строка рекурсивного запроса: категория и её расстояние от исходной.
+type categoryWithDepth struct {
+	Category
+	Depth int
+}
+
+// GetAll возвращает все категории без подкатегорий; дерево собирает сервис.
+func (repo *CategoryRepository) GetAll(ctx context.Context) ([]Category, error) {
+	var categories []Category
+	err := repo.Db.WithContext(ctx).Order("name").Find(&categories).Error
+	return categories, err
+}
+
+// GetAncestors возвращает цепочку предков от корня до непосредственного родителя.
+func (repo *CategoryRepository) GetAncestors(ctx context.Context, id uint) ([]categoryWithDepth, error) {
+	var rows []categoryWithDepth
+	err := db.RawScan(repo.Db.WithContext(ctx), &rows, ancestorsSQL, id, maxTreeDepth)
+	return rows, err
+}
+
+// GetDescendants возвращает все подкатегории на любой глубине, ближние первыми.
+func (repo *CategoryRepository) GetDescendants(ctx context.Context, id uint) ([]categoryWithDepth, error) {
+	var rows []categoryWithDepth
+	err := db.RawScan(repo.Db.WithContext(ctx), &rows, descendantsSQL, id, maxTreeDepth)
+	return rows, err
+}
+
+// Move переносит категорию вместе с поддеревом под newParentID (nil — в корень).
+// Проверка цикла и перенос выполняются под блокировкой дерева в одной транзакции.
+// Акции на категорию действуют и на подкатегории, поэтому в той же транзакции
+// пересчитываются цены вариантов всех продуктов поддерева.
+func (repo *CategoryRepository) Move(ctx context.Context, id uint, newParentID *uint, variants interfaces.VariantRepricer) error {
+	return repo.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockTree(tx); err != nil {
+			return err
+		}
+
+		var category Category
+		if err := tx.First(&category, id).Error; err != nil {
+			return err
+		}
+		if err := checkParent(tx, id, newParentID); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&Category{}).Where("id = ?", id).
+			Update("parent_category_id", newParentID).Error; err != nil {
+			return err
+		}
+		variantIDs, err := subtreeVariantIDs(tx, id)
+		if err != nil {
+			return err
+		}
+		if _, err := variants.Reprice(tx, variantIDs, time.Now()); err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntity, id, "move", map[string]interface{}{
+			"from_parent_id": category.ParentCategoryID,
+			"to_parent_id":   newParentID,
+		})
+	})
+}
+
+// subtreeVariantIDs возвращает неудалённые варианты продуктов категории id и её подкатегорий.
+func subtreeVariantIDs(tx *gorm.DB, id uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Table("product_variants pv").
+		Joins("JOIN products p ON p.id = pv.product_id AND p.deleted_at IS NULL").
+		Where("pv.deleted_at IS NULL").
+		Where("p.category_id = ? OR p.category_id IN (SELECT d.id FROM ("+descendantsSQL+") d)", id, id, maxTreeDepth).
+		Order("pv.id").
+		Pluck("pv.id", &ids).Error
+	return ids, err
+}
+
+func lockTree(tx *gorm.DB) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(?)", treeLockKey).Error
 }
 
+// checkParent проверяет, что parentID существует и не лежит в поддереве id.
+func checkParent(tx *gorm.DB, id uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	if *parentID == id {
+		return ErrCategoryCycle
+	}
+
+	var parent Category
+	if err := tx.Select("id").First(&parent, *parentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrParentNotFound
+		}
+		return err
+	}
+
+	var inSubtree int64
+	if err := db.RawScan(tx, &inSubtree, `SELECT COUNT(*) FROM (`+descendantsSQL+`) d WHERE d.id = ?`, id, maxTreeDepth, *parentID); err != nil {
+		return err
+	}
+	if inSubtree > 0 {
+		return ErrCategoryCycle
+	}
+	return nil
+}
@@ -1,90 +1,190 @@
 package category
 
 import (
+	"context"
 	"errors"
 	"fmt"
+
+	"github.com/ShopOnGO/product-service/pkg/interfaces"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryCycle    = errors.New("category cannot be moved into its own subtree")
+	ErrParentNotFound   = errors.New("parent category not found")
+	ErrHasSubcategories = errors.New("category has subcategories")
+	ErrReassignTarget   = errors.New("target_id must reference another existing category")
 )
 
 type CategoryService struct {
-	repo *CategoryRepository
+	repo     *CategoryRepository
+	products interfaces.ProductDetacher
+	variants interfaces.VariantRepricer
 }
 
-func NewCategoryService(repo *CategoryRepository) *CategoryService {
+func NewCategoryService(repo *CategoryRepository, products interfaces.ProductDetacher, variants interfaces.VariantRepricer) *CategoryService {
 	return &CategoryService{
-		repo: repo,
+		repo:     repo,
+		products: products,
+		variants: variants,
 	}
 }
 
-func (s *CategoryService) CreateCategory(category *Category) (*Category, error) {
-	existing, _ := s.repo.GetByName(category.Name)
+func (s *CategoryService) CreateCategory(ctx context.Context, category *Category) (*Category, error) {
+	existing, _ := s.repo.GetByName(ctx, category.Name)
 	if existing != nil {
 		return nil, errors.New("категория с таким именем уже существует")
 	}
 
 	if category.ParentCategoryID != nil {
-		parent, err := s.repo.GetByID(*category.ParentCategoryID)
+		parent, err := s.repo.GetByID(ctx, *category.ParentCategoryID)
 		if err != nil || parent == nil {
 			return nil, errors.New("указана несуществующая родительская категория")
 		}
 	}
-	return s.repo.Create(category)
+	return s.repo.Create(ctx, category)
 }
 
-func (s *CategoryService) GetFeaturedCategories(amount int) ([]Category, error) {
-	return s.repo.GetFeaturedCategories(amount)
+func (s *CategoryService) GetFeaturedCategories(ctx context.Context, amount int) ([]Category, error) {
+	return s.repo.GetFeaturedCategories(ctx, amount)
 }
 
-func (s *CategoryService) GetCategoryByName(name string) (*Category, error) {
-	return s.repo.GetByName(name)
+func (s *CategoryService) GetCategoryByName(ctx context.Context, name string) (*Category, error) {
+	return s.repo.GetByName(ctx, name)
 }
 
-func (s *CategoryService) GetCategoryByID(id uint) (*Category, error) {
-	return s.repo.GetByID(id)
+func (s *CategoryService) GetCategoryByID(ctx context.Context, id uint) (*Category, error) {
+	return s.repo.GetByID(ctx, id)
 }
 
-func (s *CategoryService) UpdateCategory(category *Category) (*Category, error) {
-	existing, err := s.repo.GetByID(category.ID)
+func (s *CategoryService) UpdateCategory(ctx context.Context, category *Category) (*Category, error) {
+	existing, err := s.repo.GetByID(ctx, category.ID)
 	if err != nil {
 		return nil, fmt.Errorf("категория с ID %d не найдена: %w", category.ID, err)
 	}
 
-	if category.ParentCategoryID != nil && *category.ParentCategoryID == category.ID {
-		return nil, errors.New("нельзя установить родительскую категорию саму на себя")
-	}
-
 	if category.Name != "" && category.Name != existing.Name {
-		catWithSameName, err := s.repo.GetByName(category.Name)
+		catWithSameName, err := s.repo.GetByName(ctx, category.Name)
 		if err == nil && catWithSameName != nil && catWithSameName.ID != category.ID {
 			return nil, fmt.Errorf("категория с именем %q уже существует", category.Name)
 		}
 	}
 
-	if category.ParentCategoryID != nil {
-		parent, err := s.repo.GetByID(*category.ParentCategoryID)
-		if err != nil || parent == nil {
-			return nil, errors.New("указанная родительская категория не существует")
-		}
+	// Родитель и отсутствие цикла проверяются в репозитории под блокировкой дерева,
+	// там же при смене родителя пересчитываются цены вариантов поддерева.
+	return s.repo.Update(ctx, category, s.variants)
+}
+
+// DeleteCategory удаляет категорию без подкатегорий, применяя стратегию к её продуктам,
+// и возвращает число затронутых продуктов.
+func (s *CategoryService) DeleteCategory(ctx context.Context, id uint, query DeleteCategoryQuery) (int64, error) {
+	_, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("категория с ID %d не найдена: %w", id, err)
+	}
+	if query.Strategy == interfaces.DeleteReassign && (query.TargetID == 0 || query.TargetID == id) {
+		return 0, ErrReassignTarget
 	}
 
-	return s.repo.Update(category)
+	// Подкатегории проверяются в репозитории под блокировкой дерева.
+	return s.repo.Delete(ctx, id, query, s.products)
 }
 
-func (s *CategoryService) DeleteCategory(id uint) error {
-	_, err := s.repo.GetByID(id)
+// GetTree возвращает дерево категорий. rootID == 0 — всё дерево от корней,
+// maxDepth > 0 обрезает вложенность (1 — только сами корни).
+func (s *CategoryService) GetTree(ctx context.Context, rootID uint, maxDepth int) ([]*CategoryNode, error) {
+	categories, err := s.repo.GetAll(ctx)
 	if err != nil {
-		return fmt.Errorf("категория с ID %d не найдена: %w", id, err)
+		return nil, err
 	}
 
-	var subCategories []Category
-	err = s.repo.Db.DB.Where("parent_category_id = ?", id).Find(&subCategories).Error
+	nodes := make(map[uint]*CategoryNode, len(categories))
+	for i := range categories {
+		nodes[categories[i].ID] = newCategoryNode(&categories[i], 0)
+	}
+
+	var roots []*CategoryNode
+	for i := range categories {
+		node := nodes[categories[i].ID]
+		parentID := categories[i].ParentCategoryID
+		if parentID != nil {
+			if parent, ok := nodes[*parentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		// Родитель удалён или отсутствует — категория показывается как корневая.
+		if rootID == 0 {
+			roots = append(roots, node)
+		}
+	}
+
+	if rootID != 0 {
+		root, ok := nodes[rootID]
+		if !ok {
+			return nil, fmt.Errorf("категория с ID %d не найдена: %w", rootID, gorm.ErrRecordNotFound)
+		}
+		roots = []*CategoryNode{root}
+	}
+
+	for _, root := range roots {
+		setDepth(root, 0, maxDepth)
+	}
+	return roots, nil
+}
+
+// setDepth проставляет глубину и обрезает ветви глубже maxDepth.
+// Ограничение maxTreeDepth защищает от цикла в старых данных, если rootID лежит на нём.
+func setDepth(node *CategoryNode, depth, maxDepth int) {
+	node.Depth = depth
+	if (maxDepth > 0 && depth+1 >= maxDepth) || depth+1 >= maxTreeDepth {
+		node.Children = nil
+		return
+	}
+	for _, child := range node.Children {
+		setDepth(child, depth+1, maxDepth)
+	}
+}
+
+// GetAncestors возвращает хлебные крошки: предков от корня до родителя категории.
+func (s *CategoryService) GetAncestors(ctx context.Context, id uint) ([]*CategoryNode, error) {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.GetAncestors(ctx, id)
 	if err != nil {
-		return fmt.Errorf("ошибка при проверке подкатегорий: %w", err)
+		return nil, err
 	}
 
-	if len(subCategories) > 0 {
-		return errors.New("нельзя удалить категорию, у которой есть подкатегории")
+	ancestors := make([]*CategoryNode, 0, len(rows))
+	for i := range rows {
+		// Глубина считается от корня: у первого предка 0.
+		ancestors = append(ancestors, newCategoryNode(&rows[i].Category, len(rows)-rows[i].Depth))
 	}
+	return ancestors, nil
+}
 
-	return s.repo.Delete(id)
+// GetDescendants возвращает все подкатегории на любой глубине; Depth — расстояние от id.
+func (s *CategoryService) GetDescendants(ctx context.Context, id uint) ([]*CategoryNode, error) {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.GetDescendants(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	descendants := make([]*CategoryNode, 0, len(rows))
+	for i := range rows {
+		descendants = append(descendants, newCategoryNode(&rows[i].Category, rows[i].Depth))
+	}
+	return descendants, nil
 }
 
+// MoveCategory переносит категорию вместе с подкатегориями под parentID (nil — в корень).
+func (s *CategoryService) MoveCategory(ctx context.Context, id uint, parentID *uint) (*Category, error) {
+	if err := s.repo.Move(ctx, id, parentID, s.variants); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
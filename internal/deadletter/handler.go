@@ -0,0 +1,143 @@
+package deadletter
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/product-service/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+type DeadLetterHandlerDeps struct {
+	DeadLetterSvc *DeadLetterService
+	Auth          gin.HandlerFunc // проверка JWT
+}
+
+type DeadLetterHandler struct {
+	deadLetterSvc *DeadLetterService
+}
+
+func NewDeadLetterHandler(router *gin.Engine, deps DeadLetterHandlerDeps) *DeadLetterHandler {
+	handler := &DeadLetterHandler{
+		deadLetterSvc: deps.DeadLetterSvc,
+	}
+
+	deadLetterGroup := router.Group("/product-service/admin/dead-letters", deps.Auth, auth.RequireRoles(auth.RoleAdmin))
+	{
+		deadLetterGroup.GET("", handler.ListDeadLetters)
+		deadLetterGroup.GET("/:id", handler.GetDeadLetter)
+		deadLetterGroup.POST("/:id/replay", handler.ReplayDeadLetter)
+	}
+
+	return handler
+}
+
+// ListDeadLetters возвращает сообщения, не обработанные consumer'ами.
+// @Summary Список dead letters
+// @Description Сообщения Kafka, которые не удалось обработать после всех повторов. Новые первыми.
+// @Tags Админка
+// @Produce json
+// @Param consumer query string false "Consumer: product, variant или media"
+// @Param status query string false "pending или replayed"
+// @Param page query int false "Номер страницы"
+// @Param page_size query int false "Размер страницы (до 100)"
+// @Success 200 {object} DeadLetterPage
+// @Failure 400 {object} map[string]string "Неверные параметры"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Только для администратора"
+// @Security ApiKeyAuth
+// @Router /admin/dead-letters [get]
+func (h *DeadLetterHandler) ListDeadLetters(c *gin.Context) {
+	var q DeadLetterListQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+	if q.Status != "" && q.Status != StatusPending && q.Status != StatusReplayed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending or replayed"})
+		return
+	}
+
+	page, err := h.deadLetterSvc.List(c.Request.Context(), q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetDeadLetter возвращает dead letter с исходным сообщением.
+// @Summary Dead letter по ID
+// @Tags Админка
+// @Produce json
+// @Param id path int true "ID записи"
+// @Success 200 {object} DeadLetterView
+// @Failure 400 {object} map[string]string "Неверный ID"
+// @Failure 404 {object} map[string]string "Запись не найдена"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Только для администратора"
+// @Security ApiKeyAuth
+// @Router /admin/dead-letters/{id} [get]
+func (h *DeadLetterHandler) GetDeadLetter(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dead letter id"})
+		return
+	}
+
+	letter, err := h.deadLetterSvc.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		writeDeadLetterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, letter)
+}
+
+// ReplayDeadLetter повторно обрабатывает сообщение.
+// @Summary Повтор dead letter
+// @Description Передаёт исходное сообщение обработчику consumer'а. При успехе запись помечается replayed.
+// @Tags Админка
+// @Produce json
+// @Param id path int true "ID записи"
+// @Success 200 {object} DeadLetterView
+// @Failure 400 {object} map[string]string "Неверный ID"
+// @Failure 404 {object} map[string]string "Запись не найдена"
+// @Failure 409 {object} map[string]string "Уже повторено"
+// @Failure 422 {object} map[string]string "Обработка снова завершилась ошибкой"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Только для администратора"
+// @Security ApiKeyAuth
+// @Router /admin/dead-letters/{id}/replay [post]
+func (h *DeadLetterHandler) ReplayDeadLetter(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dead letter id"})
+		return
+	}
+
+	letter, err := h.deadLetterSvc.Replay(c.Request.Context(), uint(id))
+	if errors.Is(err, ErrReplayFailed) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "dead_letter": letter})
+		return
+	}
+	if err != nil {
+		writeDeadLetterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, letter)
+}
+
+func writeDeadLetterError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrDeadLetterNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrAlreadyReplayed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		logger.Errorf("Dead letter error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,25 @@
+package deadletter
+
+import "time"
+
+// DeadLetter — сообщение, которое consumer не смог обработать после всех попыток.
+// Хранится в БД для просмотра и повтора, копия уходит в DLQ-топик consumer'а.
+type DeadLetter struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Consumer      string     `gorm:"type:varchar(50);index;not null" json:"consumer"`
+	Topic         string     `gorm:"type:varchar(255);not null" json:"topic"`
+	Partition     int        `gorm:"not null" json:"partition"`
+	Offset        int64      `gorm:"not null" json:"offset"`
+	Key           string     `gorm:"type:varchar(255)" json:"key"`
+	Payload       []byte     `gorm:"type:bytea;not null" json:"-"`
+	Error         string     `gorm:"type:text;not null" json:"error"`
+	Attempts      int        `gorm:"not null" json:"attempts"`
+	FirstFailedAt time.Time  `gorm:"not null" json:"first_failed_at"`
+	LastFailedAt  time.Time  `gorm:"not null" json:"last_failed_at"`
+	ReplayedAt    *time.Time `gorm:"index" json:"replayed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func (DeadLetter) TableName() string {
+	return "dead_letters"
+}
@@ -0,0 +1,37 @@
+package deadletter
+
+import "time"
+
+// DeadLetterEvent — сообщение DLQ-топика: исходное сообщение и история ошибок.
+type DeadLetterEvent struct {
+	Consumer      string    `json:"consumer"`
+	Topic         string    `json:"topic"`
+	Partition     int       `json:"partition"`
+	Offset        int64     `json:"offset"`
+	Key           string    `json:"key"`
+	Payload       string    `json:"payload"`
+	Error         string    `json:"error"`
+	Attempts      int       `json:"attempts"`
+	FirstFailedAt time.Time `json:"first_failed_at"`
+	LastFailedAt  time.Time `json:"last_failed_at"`
+}
+
+// DeadLetterView — запись для админки вместе с исходным сообщением.
+type DeadLetterView struct {
+	DeadLetter
+	Payload string `json:"payload"`
+}
+
+type DeadLetterListQuery struct {
+	Consumer string `form:"consumer"`
+	Status   string `form:"status"` // pending | replayed, по умолчанию все
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+}
+
+type DeadLetterPage struct {
+	Items    []DeadLetterView `json:"items"`
+	Total    int64            `json:"total"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+}
@@ -0,0 +1,75 @@
+package deadletter
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ShopOnGO/product-service/internal/audit"
+	"github.com/ShopOnGO/product-service/pkg/db"
+	"gorm.io/gorm"
+)
+
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+type DeadLetterRepository struct {
+	Database *db.Db
+}
+
+func NewDeadLetterRepository(database *db.Db) *DeadLetterRepository {
+	return &DeadLetterRepository{
+		Database: database,
+	}
+}
+
+func (repo *DeadLetterRepository) Create(ctx context.Context, letter *DeadLetter) error {
+	return repo.Database.DB.WithContext(ctx).Create(letter).Error
+}
+
+func (repo *DeadLetterRepository) GetByID(ctx context.Context, id uint) (*DeadLetter, error) {
+	var letter DeadLetter
+	err := repo.Database.DB.WithContext(ctx).First(&letter, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &letter, nil
+}
+
+// List возвращает страницу записей, новые первыми.
+func (repo *DeadLetterRepository) List(ctx context.Context, consumer, status string, limit, offset int) ([]DeadLetter, int64, error) {
+	query := repo.Database.DB.WithContext(ctx).Model(&DeadLetter{})
+	if consumer != "" {
+		query = query.Where("consumer = ?", consumer)
+	}
+	switch status {
+	case StatusPending:
+		query = query.Where("replayed_at IS NULL")
+	case StatusReplayed:
+		query = query.Where("replayed_at IS NOT NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var letters []DeadLetter
+	err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&letters).Error
+	return letters, total, err
+}
+
+// UpdateReplayed сохраняет результат повтора и записывает его в журнал аудита.
+func (repo *DeadLetterRepository) UpdateReplayed(ctx context.Context, letter *DeadLetter) error {
+	return repo.Database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(letter).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, "dead_letter", letter.ID, "replay", map[string]interface{}{
+			"consumer": letter.Consumer,
+			"replayed": letter.ReplayedAt != nil,
+			"error":    letter.Error,
+		})
+	})
+}
@@ -0,0 +1,112 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/product-service/internal/attribute"
+	"github.com/ShopOnGO/product-service/internal/auth"
+	"github.com/ShopOnGO/product-service/internal/product"
+	"github.com/ShopOnGO/product-service/internal/productVariant"
+	"github.com/segmentio/kafka-go"
+	"gorm.io/gorm"
+)
+
+// RetryPolicy — сколько раз и с какой задержкой повторять обработку перед отправкой в DLQ.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// backoff возвращает задержку перед попыткой attempt+1: InitialBackoff, 2x, 4x, ... не больше MaxBackoff.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+// WithRetry оборачивает обработчик consumer'а: ошибка повторяется по политике,
+// а после последней попытки сообщение уходит в dead letters, и чтение топика продолжается.
+// Ошибки разбора JSON, отказ в доступе, невалидные данные и отсутствующие записи
+// не повторяются — повтор их не исправит.
+func WithRetry(ctx context.Context, svc *DeadLetterService, consumer string, policy RetryPolicy, handler func(ctx context.Context, msg kafka.Message) error) func(ctx context.Context, msg kafka.Message) error {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	return func(msgCtx context.Context, msg kafka.Message) error {
+		var (
+			err         error
+			attempts    int
+			firstFailed time.Time
+		)
+		for attempts < policy.MaxAttempts {
+			attempts++
+			if err = handler(msgCtx, msg); err == nil {
+				return nil
+			}
+			if firstFailed.IsZero() {
+				firstFailed = time.Now()
+			}
+			if isPermanent(err) || attempts == policy.MaxAttempts {
+				break
+			}
+
+			delay := policy.backoff(attempts)
+			logger.Warnf("Consumer %s: попытка %d/%d для %s/%d/%d не удалась, повтор через %s: %v",
+				consumer, attempts, policy.MaxAttempts, msg.Topic, msg.Partition, msg.Offset, delay, err)
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				// Офсет уже зафиксирован при чтении: при остановке сервиса сообщение
+				// сохраняется в dead letters, а не теряется вместе с оставшимися повторами.
+				timer.Stop()
+				logger.Warnf("Consumer %s: остановка во время повторов, сообщение %s/%d/%d отправлено в DLQ",
+					consumer, msg.Topic, msg.Partition, msg.Offset)
+				return svc.Record(msgCtx, consumer, msg, err, attempts, firstFailed, time.Now())
+			case <-timer.C:
+			}
+		}
+
+		logger.Errorf("Consumer %s: сообщение %s/%d/%d отправлено в DLQ после %d попыток: %v",
+			consumer, msg.Topic, msg.Partition, msg.Offset, attempts, err)
+		return svc.Record(msgCtx, consumer, msg, err, attempts, firstFailed, time.Now())
+	}
+}
+
+// permanentErrors — ошибки проверки данных и отсутствующие записи: повтор того же сообщения
+// их не исправит, поэтому оно сразу уходит в dead letters, откуда его можно переиграть.
+var permanentErrors = []error{
+	auth.ErrUnauthenticated,
+	auth.ErrForbidden,
+	gorm.ErrRecordNotFound,
+	product.ErrInvalidProduct,
+	product.ErrProductNotFound,
+	attribute.ErrInvalidAttribute,
+	attribute.ErrUnknownAttribute,
+	attribute.ErrCategoryNotFound,
+	productVariant.ErrInvalidReservation,
+	productVariant.ErrReservationNotFound,
+}
+
+func isPermanent(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return true
+	}
+	for _, permanent := range permanentErrors {
+		if errors.Is(err, permanent) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/product-service/internal/auth"
+	"github.com/ShopOnGO/product-service/internal/metrics"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusReplayed = "replayed"
+
+	defaultPageSize = 20
+	maxPageSize     = 100
+	publishTimeout  = 10 * time.Second
+	replayTimeout   = 30 * time.Second
+)
+
+var (
+	ErrUnknownConsumer = errors.New("unknown consumer")
+	ErrAlreadyReplayed = errors.New("dead letter already replayed")
+	ErrReplayFailed    = errors.New("replay failed")
+)
+
+type DeadLetterService struct {
+	repo      *DeadLetterRepository
+	producers map[string]*kafkaService.KafkaService                         // DLQ-продюсер каждого consumer'а
+	handlers  map[string]func(ctx context.Context, msg kafka.Message) error // обработчики для повтора
+}
+
+func NewDeadLetterService(repo *DeadLetterRepository) *DeadLetterService {
+	return &DeadLetterService{
+		repo:      repo,
+		producers: map[string]*kafkaService.KafkaService{},
+		handlers:  map[string]func(ctx context.Context, msg kafka.Message) error{},
+	}
+}
+
+// RegisterConsumer связывает consumer с его DLQ-продюсером и обработчиком, которым выполняется повтор.
+func (s *DeadLetterService) RegisterConsumer(consumer string, dlq *kafkaService.KafkaService, handler func(ctx context.Context, msg kafka.Message) error) {
+	s.producers[consumer] = dlq
+	s.handlers[consumer] = handler
+}
+
+// Record сохраняет сообщение в БД и публикует его в DLQ-топик consumer'а.
+// Запись в БД первична: без неё сообщение нельзя будет повторить из админки.
+// Публикация в DLQ продолжает трейс исходного сообщения.
+func (s *DeadLetterService) Record(ctx context.Context, consumer string, msg kafka.Message, cause error, attempts int, firstFailedAt, lastFailedAt time.Time) error {
+	letter := &DeadLetter{
+		Consumer:      consumer,
+		Topic:         msg.Topic,
+		Partition:     msg.Partition,
+		Offset:        msg.Offset,
+		Key:           string(msg.Key),
+		Payload:       msg.Value,
+		Error:         cause.Error(),
+		Attempts:      attempts,
+		FirstFailedAt: firstFailedAt,
+		LastFailedAt:  lastFailedAt,
+	}
+	if err := s.repo.Create(ctx, letter); err != nil {
+		return fmt.Errorf("save dead letter: %w", err)
+	}
+	metrics.KafkaMessagesDeadLettered.WithLabelValues(consumer).Inc()
+
+	producer := s.producers[consumer]
+	if producer == nil {
+		logger.Warnf("DLQ producer for consumer %s is not configured, dead letter %d saved to DB only", consumer, letter.ID)
+		return nil
+	}
+
+	value, err := json.Marshal(DeadLetterEvent{
+		Consumer:      letter.Consumer,
+		Topic:         letter.Topic,
+		Partition:     letter.Partition,
+		Offset:        letter.Offset,
+		Key:           letter.Key,
+		Payload:       string(letter.Payload),
+		Error:         letter.Error,
+		Attempts:      letter.Attempts,
+		FirstFailedAt: letter.FirstFailedAt,
+		LastFailedAt:  letter.LastFailedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	if err := metrics.Produce(ctx, producer, kafka.Message{Key: msg.Key, Value: value}); err != nil {
+		return fmt.Errorf("publish dead letter %d: %w", letter.ID, err)
+	}
+	return nil
+}
+
+func (s *DeadLetterService) List(ctx context.Context, q DeadLetterListQuery) (*DeadLetterPage, error) {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = defaultPageSize
+	}
+	if q.PageSize > maxPageSize {
+		q.PageSize = maxPageSize
+	}
+
+	letters, total, err := s.repo.List(ctx, q.Consumer, q.Status, q.PageSize, (q.Page-1)*q.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]DeadLetterView, 0, len(letters))
+	for _, letter := range letters {
+		items = append(items, newDeadLetterView(letter))
+	}
+	return &DeadLetterPage{
+		Items:    items,
+		Total:    total,
+		Page:     q.Page,
+		PageSize: q.PageSize,
+	}, nil
+}
+
+func (s *DeadLetterService) Get(ctx context.Context, id uint) (*DeadLetterView, error) {
+	letter, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	view := newDeadLetterView(*letter)
+	return &view, nil
+}
+
+// Replay повторно обрабатывает сообщение тем же обработчиком consumer'а.
+// Успех отмечается ReplayedAt; при ошибке обновляются текст ошибки и счётчик попыток.
+// Обработчик получает контекст без администратора из HTTP-запроса и без его отмены,
+// как у consumer'а: права определяет само сообщение.
+func (s *DeadLetterService) Replay(ctx context.Context, id uint) (*DeadLetterView, error) {
+	letter, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if letter.ReplayedAt != nil {
+		return nil, ErrAlreadyReplayed
+	}
+	handler := s.handlers[letter.Consumer]
+	if handler == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownConsumer, letter.Consumer)
+	}
+
+	replayCtx, cancel := context.WithTimeout(auth.WithoutActor(context.WithoutCancel(ctx)), replayTimeout)
+	defer cancel()
+	handleErr := handler(replayCtx, kafka.Message{
+		Topic:     letter.Topic,
+		Partition: letter.Partition,
+		Offset:    letter.Offset,
+		Key:       []byte(letter.Key),
+		Value:     letter.Payload,
+	})
+
+	now := time.Now()
+	letter.Attempts++
+	if handleErr != nil {
+		letter.Error = handleErr.Error()
+		letter.LastFailedAt = now
+	} else {
+		letter.ReplayedAt = &now
+	}
+	if err := s.repo.UpdateReplayed(ctx, letter); err != nil {
+		return nil, err
+	}
+
+	view := newDeadLetterView(*letter)
+	if handleErr != nil {
+		return &view, fmt.Errorf("%w: %v", ErrReplayFailed, handleErr)
+	}
+	return &view, nil
+}
+
+func newDeadLetterView(letter DeadLetter) DeadLetterView {
+	return DeadLetterView{DeadLetter: letter, Payload: string(letter.Payload)}
+}
@@ -1,7 +1,6 @@
 package grpc
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -10,32 +9,40 @@ import (
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
 	pb "github.com/ShopOnGO/review-proto/pkg/service"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 type ReviewHandler struct {
 	Clients *GRPCClients
 }
 
-func InitGRPCClients() *GRPCClients {
-	conn, err := grpc.Dial(fmt.Sprintf("%s:%s", os.Getenv("REVIEW_SERVICE_HOST"), os.Getenv("REVIEW_SERVICE_PORT")), grpc.WithInsecure())
+// InitGRPCClients создаёт клиентов review-service. Соединение устанавливается лениво,
+// поэтому ошибка здесь означает неверный адрес, а доступность сервиса видна в /readyz.
+func InitGRPCClients() (*GRPCClients, error) {
+	conn, err := grpc.NewClient(
+		fmt.Sprintf("%s:%s", os.Getenv("REVIEW_SERVICE_HOST"), os.Getenv("REVIEW_SERVICE_PORT")),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
 	if err != nil {
-		logger.Errorf("Ошибка подключения к gRPC серверу: %v", err)
+		return nil, fmt.Errorf("review-service client: %w", err)
 	}
 
-	logger.Info("gRPC connected")
 	reviewClient := pb.NewReviewServiceClient(conn)
 	questionClient := pb.NewQuestionServiceClient(conn)
 
 	return &GRPCClients{
+		Conn:           conn,
 		ReviewClient:   reviewClient,
 		QuestionClient: questionClient,
-	}
+	}, nil
 }
 
-func NewReviewHandler(router *gin.Engine) {
+func NewReviewHandler(router *gin.Engine, clients *GRPCClients) {
 	handler := &ReviewHandler{
-		Clients: InitGRPCClients(),
+		Clients: clients,
 	}
 
 	productGroup := router.Group("/product-service/products")
@@ -59,7 +66,7 @@ func NewReviewHandler(router *gin.Engine) {
 // @Failure 500 {object} map[string]string "Ошибка получения отзывов"
 // @Router /products/reviews/{id} [get]
 func (h *ReviewHandler) GetProductWithReviews(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	limitStr := c.Query("limit")
 	offsetStr := c.Query("offset")
 
@@ -100,7 +107,7 @@ func (h *ReviewHandler) GetProductWithReviews(c *gin.Context) {
 // @Failure 500 {object} map[string]string "Ошибка получения вопросов"
 // @Router /products/questions/{id} [get]
 func (h *ReviewHandler) GetProductWithQuestions(c *gin.Context) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	limitStr := c.Query("limit")
 	offsetStr := c.Query("offset")
 
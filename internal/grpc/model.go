@@ -2,9 +2,11 @@ package grpc
 
 import (
 	pb "github.com/ShopOnGO/review-proto/pkg/service"
+	"google.golang.org/grpc"
 )
 
 type GRPCClients struct {
-	ReviewClient	pb.ReviewServiceClient
-	QuestionClient	pb.QuestionServiceClient
-}
\ No newline at end of file
+	Conn           *grpc.ClientConn
+	ReviewClient   pb.ReviewServiceClient
+	QuestionClient pb.QuestionServiceClient
+}
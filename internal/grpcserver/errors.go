@@ -0,0 +1,61 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ShopOnGO/product-service/internal/attribute"
+	"github.com/ShopOnGO/product-service/internal/auth"
+	"github.com/ShopOnGO/product-service/internal/product"
+	"github.com/ShopOnGO/product-service/internal/productVariant"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// errorCodes — соответствие ошибок сервисов кодам gRPC; проверяется по порядку через errors.Is.
+var errorCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{context.DeadlineExceeded, codes.DeadlineExceeded},
+	{context.Canceled, codes.Canceled},
+
+	{gorm.ErrRecordNotFound, codes.NotFound},
+	{product.ErrProductNotFound, codes.NotFound},
+	{productVariant.ErrReservationNotFound, codes.NotFound},
+	{attribute.ErrCategoryNotFound, codes.NotFound},
+
+	{auth.ErrUnauthenticated, codes.Unauthenticated},
+	{auth.ErrForbidden, codes.PermissionDenied},
+
+	{product.ErrInvalidProduct, codes.InvalidArgument},
+	{product.ErrInvalidCursor, codes.InvalidArgument},
+	{product.ErrInvalidSort, codes.InvalidArgument},
+	{product.ErrInvalidPriceSpan, codes.InvalidArgument},
+	{product.ErrInvalidSearch, codes.InvalidArgument},
+	{productVariant.ErrInvalidSort, codes.InvalidArgument},
+	{productVariant.ErrInvalidPriceSpan, codes.InvalidArgument},
+	{productVariant.ErrInvalidReservation, codes.InvalidArgument},
+	{attribute.ErrUnknownAttribute, codes.InvalidArgument},
+	{attribute.ErrInvalidAttribute, codes.InvalidArgument},
+	{attribute.ErrInvalidDefinition, codes.InvalidArgument},
+
+	{productVariant.ErrNotEnoughStock, codes.FailedPrecondition},
+	{productVariant.ErrInvalidReservationTransition, codes.FailedPrecondition},
+	{productVariant.ErrStockBelowReserved, codes.FailedPrecondition},
+}
+
+// toStatus переводит ошибку обработчика в gRPC-статус. Готовые статусы (например, с деталями
+// недостачи) не меняются, а текст неизвестных ошибок не уходит клиенту — он остаётся в логе.
+func toStatus(err error) *status.Status {
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+	for _, m := range errorCodes {
+		if errors.Is(err, m.err) {
+			return status.New(m.code, err.Error())
+		}
+	}
+	return status.New(codes.Internal, "internal error")
+}
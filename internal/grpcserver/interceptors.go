@@ -0,0 +1,158 @@
+package grpcserver
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDKey — ключ метаданных с ID запроса: берётся из входящих, иначе создаётся,
+// и возвращается клиенту в заголовках ответа.
+const RequestIDKey = "x-request-id"
+
+type requestIDCtxKey struct{}
+
+// RequestID возвращает ID текущего gRPC-запроса или пустую строку.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDCtxKey{}).(string)
+	return id
+}
+
+func withRequestID(ctx context.Context) (context.Context, string) {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDKey); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	if id == "" {
+		id = uuid.NewString()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, id))
+	return context.WithValue(ctx, requestIDCtxKey{}, id), id
+}
+
+// logCall пишет строку о завершённом вызове; внутренние ошибки — с исходным текстом.
+func logCall(method, requestID string, started time.Time, err, cause error) {
+	code := status.Code(err)
+	elapsed := time.Since(started)
+	switch code {
+	case codes.OK:
+		logger.Infof("gRPC %s request_id=%s code=%s duration=%s", method, requestID, code, elapsed)
+	case codes.Internal, codes.Unknown:
+		logger.Errorf("gRPC %s request_id=%s code=%s duration=%s error=%v", method, requestID, code, elapsed, cause)
+	default:
+		logger.Warnf("gRPC %s request_id=%s code=%s duration=%s error=%v", method, requestID, code, elapsed, err)
+	}
+}
+
+// UnaryLogging присваивает вызову ID запроса, переводит ошибки в статусы и логирует результат.
+// Стоит первым в цепочке, чтобы видеть итоговый код, в том числе после паники.
+func UnaryLogging() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		started := time.Now()
+		ctx, requestID := withRequestID(ctx)
+		resp, cause := handler(ctx, req)
+		var err error
+		if cause != nil {
+			err = toStatus(cause).Err()
+		}
+		logCall(info.FullMethod, requestID, started, err, cause)
+		return resp, err
+	}
+}
+
+func StreamLogging() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		started := time.Now()
+		ctx, requestID := withRequestID(ss.Context())
+		cause := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		var err error
+		if cause != nil {
+			err = toStatus(cause).Err()
+		}
+		logCall(info.FullMethod, requestID, started, err, cause)
+		return err
+	}
+}
+
+// UnaryErrors переводит ошибки сервисов в gRPC-статусы для внутренних интерцепторов
+// (метрики считают коды уже после перевода).
+func UnaryErrors() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, &mappedError{st: toStatus(err), cause: err}
+		}
+		return resp, nil
+	}
+}
+
+// mappedError несёт статус для клиента и исходную ошибку для лога.
+type mappedError struct {
+	st    *status.Status
+	cause error
+}
+
+func (e *mappedError) Error() string              { return e.cause.Error() }
+func (e *mappedError) Unwrap() error              { return e.cause }
+func (e *mappedError) GRPCStatus() *status.Status { return e.st }
+
+// UnaryRecovery превращает панику обработчика в INTERNAL, не роняя сервер.
+func UnaryRecovery() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Errorf("gRPC %s request_id=%s panic: %v\n%s", info.FullMethod, RequestID(ctx), r, debug.Stack())
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func StreamRecovery() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Errorf("gRPC %s request_id=%s panic: %v\n%s", info.FullMethod, RequestID(ss.Context()), r, debug.Stack())
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// UnaryDeadline ограничивает вызов без deadline клиента сроком def. Контекст с дедлайном
+// доходит до репозиториев через WithContext, поэтому запросы к базе отменяются вместе с вызовом.
+// Потоки не ограничиваются: массовая выгрузка может идти дольше любого разумного срока.
+func UnaryDeadline(def time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+		if _, ok := ctx.Deadline(); !ok && def > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, def)
+			defer cancel()
+		}
+		return handler(ctx, req)
+	}
+}
+
+// contextStream подменяет контекст потока, чтобы обработчик видел ID запроса.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,30 @@
+package grpcserver
+
+import (
+	"github.com/ShopOnGO/product-service/configs"
+	"github.com/ShopOnGO/product-service/internal/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+)
+
+// NewServer создаёт gRPC-сервер с ограничениями размера сообщений и цепочкой интерцепторов:
+// ID запроса и лог → метрики → восстановление после паники → перевод ошибок → дедлайн.
+func NewServer(conf configs.GRPCConfig) *grpc.Server {
+	return grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.MaxRecvMsgSize(conf.MaxRecvMsgSize),
+		grpc.MaxSendMsgSize(conf.MaxSendMsgSize),
+		grpc.ChainUnaryInterceptor(
+			UnaryLogging(),
+			metrics.UnaryServerInterceptor(),
+			UnaryRecovery(),
+			UnaryErrors(),
+			UnaryDeadline(conf.DefaultTimeout),
+		),
+		grpc.ChainStreamInterceptor(
+			StreamLogging(),
+			metrics.StreamServerInterceptor(),
+			StreamRecovery(),
+		),
+	)
+}
@@ -0,0 +1,89 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ShopOnGO/product-service/pkg/db"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Check проверяет одну зависимость; nil — зависимость доступна.
+type Check func(ctx context.Context) error
+
+// PostgresCheck пингует пул соединений с БД.
+func PostgresCheck(database *db.Db) Check {
+	return func(ctx context.Context) error {
+		sqlDB, err := database.DB.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// KafkaCheck подключается к первому доступному брокеру и проверяет, что топики существуют.
+func KafkaCheck(brokers []string, topics []string) Check {
+	return func(ctx context.Context) error {
+		var dialErr error
+		for _, broker := range brokers {
+			if broker == "" {
+				continue
+			}
+			conn, err := kafka.DialContext(ctx, "tcp", broker)
+			if err != nil {
+				dialErr = err
+				continue
+			}
+			defer conn.Close()
+
+			if len(topics) == 0 {
+				return nil
+			}
+			partitions, err := conn.ReadPartitions(topics...)
+			if err != nil {
+				return err
+			}
+			found := make(map[string]bool, len(topics))
+			for _, p := range partitions {
+				found[p.Topic] = true
+			}
+			for _, topic := range topics {
+				if !found[topic] {
+					return fmt.Errorf("topic %q not found", topic)
+				}
+			}
+			return nil
+		}
+		if dialErr == nil {
+			dialErr = errors.New("no kafka brokers configured")
+		}
+		return dialErr
+	}
+}
+
+// GRPCCheck проверяет удалённый gRPC-сервис через grpc.health.v1.
+// Если сервис не реализует health, достаточно того, что он ответил.
+func GRPCCheck(conn *grpc.ClientConn) Check {
+	return func(ctx context.Context) error {
+		if conn == nil {
+			return errors.New("client connection is not initialized")
+		}
+		resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		if status.Code(err) == codes.Unimplemented {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("service status %s", resp.GetStatus())
+		}
+		return nil
+	}
+}
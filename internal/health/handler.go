@@ -0,0 +1,53 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandlerDeps struct {
+	HealthSvc *HealthService
+}
+
+type HealthHandler struct {
+	healthSvc *HealthService
+}
+
+func NewHealthHandler(router *gin.Engine, deps HealthHandlerDeps) *HealthHandler {
+	handler := &HealthHandler{
+		healthSvc: deps.HealthSvc,
+	}
+
+	router.GET("/healthz", handler.Liveness)
+	router.GET("/readyz", handler.Readiness)
+
+	return handler
+}
+
+// Liveness сообщает, что процесс жив; зависимости не проверяются.
+// @Summary Liveness-проба
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /healthz [get]
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": StatusUp})
+}
+
+// Readiness проверяет Postgres, Kafka и review-service.
+// @Summary Readiness-проба
+// @Description Статус каждой зависимости. 503, если хотя бы одна недоступна или сервис останавливается.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} Report
+// @Failure 503 {object} Report
+// @Router /readyz [get]
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.healthSvc.Readiness(c.Request.Context())
+	code := http.StatusOK
+	if report.Status != StatusUp {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, report)
+}
@@ -0,0 +1,130 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	checkTimeout = 2 * time.Second
+)
+
+type DependencyStatus struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+type Report struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+// HealthService собирает проверки зависимостей и отражает их в HTTP /readyz и grpc.health.v1.
+type HealthService struct {
+	checks       map[string]Check
+	grpcHealth   *grpchealth.Server
+	shuttingDown atomic.Bool
+}
+
+func NewHealthService(checks map[string]Check) *HealthService {
+	return &HealthService{
+		checks:     checks,
+		grpcHealth: grpchealth.NewServer(),
+	}
+}
+
+// GRPCServer — реализация grpc.health.v1 для регистрации на gRPC-сервере.
+func (s *HealthService) GRPCServer() *grpchealth.Server {
+	return s.grpcHealth
+}
+
+// Readiness параллельно выполняет все проверки; сервис готов, только если доступны все зависимости.
+func (s *HealthService) Readiness(ctx context.Context) Report {
+	report := Report{
+		Status:       StatusUp,
+		Dependencies: make(map[string]DependencyStatus, len(s.checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range s.checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			started := time.Now()
+			err := check(checkCtx)
+			result := DependencyStatus{Status: StatusUp, LatencyMs: time.Since(started).Milliseconds()}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			report.Dependencies[name] = result
+			if err != nil {
+				report.Status = StatusDown
+			}
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	if s.shuttingDown.Load() {
+		report.Status = StatusDown
+	}
+	return report
+}
+
+// Watch периодически обновляет статус grpc.health.v1 по результатам Readiness.
+func (s *HealthService) Watch(ctx context.Context, interval time.Duration) {
+	s.updateGRPCStatus(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.updateGRPCStatus(ctx)
+		}
+	}
+}
+
+// Shutdown переводит сервис в NOT_SERVING до остановки серверов, чтобы балансировщик снял трафик.
+func (s *HealthService) Shutdown() {
+	s.shuttingDown.Store(true)
+	s.grpcHealth.Shutdown()
+}
+
+func (s *HealthService) updateGRPCStatus(ctx context.Context) {
+	if s.shuttingDown.Load() {
+		return
+	}
+	report := s.Readiness(ctx)
+	servingStatus := healthpb.HealthCheckResponse_SERVING
+	if report.Status != StatusUp {
+		servingStatus = healthpb.HealthCheckResponse_NOT_SERVING
+		for name, dep := range report.Dependencies {
+			if dep.Status != StatusUp {
+				logger.Warnf("Dependency %s is down: %s", name, dep.Error)
+			}
+		}
+	}
+	s.grpcHealth.SetServingStatus("", servingStatus)
+}
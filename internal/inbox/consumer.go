@@ -0,0 +1,41 @@
+package inbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/segmentio/kafka-go"
+)
+
+// envelope — общая часть всех входящих событий, нужная для дедупликации.
+type envelope struct {
+	EventID string `json:"event_id"`
+}
+
+// Idempotent оборачивает обработчик consumer'а: повторно доставленное событие пропускается.
+// Ключ — event_id из конверта; для старых продюсеров без event_id используется
+// позиция сообщения в топике, что защищает хотя бы от повторного чтения того же сообщения.
+func Idempotent(repo *InboxRepository, consumer string, handler func(ctx context.Context, msg kafka.Message) error) func(ctx context.Context, msg kafka.Message) error {
+	return func(ctx context.Context, msg kafka.Message) error {
+		eventID := messageEventID(msg)
+		err := repo.Process(ctx, consumer, eventID, func() error {
+			return handler(ctx, msg)
+		})
+		if errors.Is(err, ErrAlreadyProcessed) {
+			logger.Infof("Событие %s уже обработано consumer'ом %s, пропускаем", eventID, consumer)
+			return nil
+		}
+		return err
+	}
+}
+
+func messageEventID(msg kafka.Message) string {
+	var env envelope
+	if err := json.Unmarshal(msg.Value, &env); err == nil && env.EventID != "" {
+		return env.EventID
+	}
+	return fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset)
+}
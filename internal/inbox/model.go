@@ -0,0 +1,10 @@
+package inbox
+
+import "time"
+
+// ProcessedEvent — отметка о том, что consumer уже обработал событие с этим ключом.
+type ProcessedEvent struct {
+	Consumer    string    `gorm:"type:varchar(50);primaryKey"`
+	EventID     string    `gorm:"type:varchar(255);primaryKey"`
+	ProcessedAt time.Time `gorm:"index;not null"`
+}
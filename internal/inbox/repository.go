@@ -0,0 +1,51 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ShopOnGO/product-service/pkg/db"
+	"gorm.io/gorm/clause"
+)
+
+// ErrAlreadyProcessed возвращается из Process, если событие уже было обработано.
+var ErrAlreadyProcessed = errors.New("event already processed")
+
+type InboxRepository struct {
+	Database *db.Db
+}
+
+func NewInboxRepository(database *db.Db) *InboxRepository {
+	return &InboxRepository{
+		Database: database,
+	}
+}
+
+// Process выполняет handle, если событие (consumer, eventID) ещё не отмечено как обработанное,
+// и ставит отметку после его успеха. Отдельной транзакции вокруг handle нет: обработчик пишет
+// через свои репозитории, и держать ради отметки второе соединение и долгую транзакцию незачем.
+// При ошибке handle отметки нет, и событие обработается повторно. Дедупликация best-effort:
+// при параллельной доставке того же события или падении между handle и отметкой handle
+// выполнится ещё раз, поэтому обработчики должны быть идемпотентны.
+func (repo *InboxRepository) Process(ctx context.Context, consumer, eventID string, handle func() error) error {
+	var processed int64
+	if err := repo.Database.DB.WithContext(ctx).Model(&ProcessedEvent{}).
+		Where("consumer = ? AND event_id = ?", consumer, eventID).
+		Count(&processed).Error; err != nil {
+		return err
+	}
+	if processed > 0 {
+		return ErrAlreadyProcessed
+	}
+
+	if err := handle(); err != nil {
+		return err
+	}
+
+	return repo.Database.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&ProcessedEvent{
+		Consumer:    consumer,
+		EventID:     eventID,
+		ProcessedAt: time.Now(),
+	}).Error
+}
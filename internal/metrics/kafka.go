@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
+	"github.com/ShopOnGO/product-service/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// InstrumentConsumer считает обработанные и неудачные сообщения по топику и action из конверта.
+func InstrumentConsumer(handler func(ctx context.Context, msg kafka.Message) error) func(ctx context.Context, msg kafka.Message) error {
+	return func(ctx context.Context, msg kafka.Message) error {
+		action := messageAction(msg)
+		if err := handler(ctx, msg); err != nil {
+			KafkaMessagesFailed.WithLabelValues(msg.Topic, action).Inc()
+			return err
+		}
+		KafkaMessagesProcessed.WithLabelValues(msg.Topic, action).Inc()
+		return nil
+	}
+}
+
+func messageAction(msg kafka.Message) string {
+	var env struct {
+		Action string `json:"action"`
+	}
+	if err := json.Unmarshal(msg.Value, &env); err != nil || env.Action == "" {
+		return "unknown"
+	}
+	return env.Action
+}
+
+// Produce публикует сообщение в спане producer'а с контекстом трейса в заголовках
+// и записывает длительность и ошибки публикации.
+func Produce(ctx context.Context, producer *kafkaService.KafkaService, msg kafka.Message) error {
+	topic := producer.Writer.Topic
+	ctx, span := tracing.StartProducerSpan(ctx, topic, &msg)
+	defer span.End()
+
+	started := time.Now()
+	err := producer.ProduceMessage(ctx, msg)
+	KafkaProduceDuration.WithLabelValues(topic).Observe(time.Since(started).Seconds())
+	if err != nil {
+		KafkaProduceErrors.WithLabelValues(topic).Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// consumerLagCollector читает отставание consumer'ов из статистики kafka-go в момент scrape.
+type consumerLagCollector struct {
+	consumers map[string]*kafkaService.KafkaService
+	lag       *prometheus.Desc
+}
+
+// RegisterConsumerLag публикует отставание каждого consumer'а как product_service_kafka_consumer_lag.
+func RegisterConsumerLag(consumers map[string]*kafkaService.KafkaService) {
+	prometheus.MustRegister(&consumerLagCollector{
+		consumers: consumers,
+		lag: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "kafka", "consumer_lag"),
+			"Отставание consumer'а от конца топика в сообщениях.",
+			[]string{"consumer", "topic"}, nil,
+		),
+	})
+}
+
+func (c *consumerLagCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.lag
+}
+
+func (c *consumerLagCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, consumer := range c.consumers {
+		if consumer == nil || consumer.Reader == nil {
+			continue
+		}
+		// Reader.Lag() в режиме consumer group всегда -1, поэтому берём lag из Stats().
+		stats := consumer.Reader.Stats()
+		ch <- prometheus.MustNewConstMetric(c.lag, prometheus.GaugeValue, float64(stats.Lag), name, stats.Topic)
+	}
+}
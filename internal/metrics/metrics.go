@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "product_service"
+
+var (
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Длительность HTTP-запросов по маршруту, методу и статусу.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	GRPCRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "Длительность unary gRPC-вызовов по методу и коду ответа.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	GRPCStreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_stream_duration_seconds",
+		Help:      "Длительность потоковых gRPC-вызовов по методу и коду ответа.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 4, 8), // от 100 мс до ~27 минут
+	}, []string{"method", "code"})
+
+	KafkaMessagesProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_messages_processed_total",
+		Help:      "Успешно обработанные сообщения Kafka по топику и action.",
+	}, []string{"topic", "action"})
+
+	KafkaMessagesFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_messages_failed_total",
+		Help:      "Неудачные попытки обработки сообщений Kafka по топику и action.",
+	}, []string{"topic", "action"})
+
+	KafkaMessagesDeadLettered = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_messages_dead_lettered_total",
+		Help:      "Сообщения, отправленные в dead letters после всех повторов.",
+	}, []string{"consumer"})
+
+	KafkaProduceDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "kafka_produce_duration_seconds",
+		Help:      "Длительность публикации в Kafka по топику.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	KafkaProduceErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_produce_errors_total",
+		Help:      "Ошибки публикации в Kafka по топику.",
+	}, []string{"topic"})
+
+	Reservations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reservations_total",
+		Help:      "Брони по исходу: created, rejected (не хватило товара) или committed (товар отгружен).",
+	}, []string{"result"})
+
+	ReservationReleases = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reservation_releases_total",
+		Help:      "Брони, вернувшие товар в свободный остаток, по причине: cancelled или expired.",
+	}, []string{"reason"})
+
+	StockOuts = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stock_outs_total",
+		Help:      "Позиции, которые не удалось забронировать из-за нехватки остатка.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		HTTPRequestDuration,
+		GRPCRequestDuration,
+		GRPCStreamDuration,
+		KafkaMessagesProcessed,
+		KafkaMessagesFailed,
+		KafkaMessagesDeadLettered,
+		KafkaProduceDuration,
+		KafkaProduceErrors,
+		Reservations,
+		ReservationReleases,
+		StockOuts,
+	)
+}
@@ -0,0 +1,42 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Message — событие, записанное в одной транзакции с изменением данных.
+// Relay публикует его в Kafka и проставляет SentAt.
+type Message struct {
+	ID            uint       `gorm:"primaryKey"`
+	Producer      string     `gorm:"type:varchar(50);not null"`  // ключ продюсера из KAFKA_PRODUCER_TOPIC, например "products"
+	Key           string     `gorm:"type:varchar(100);not null"` // ключ сообщения Kafka, например "product-created"
+	Payload       []byte     `gorm:"type:bytea;not null"`
+	TraceContext  string     `gorm:"type:text"` // W3C-заголовки трейса транзакции в JSON, продолжаются при публикации
+	Attempts      uint       `gorm:"not null;default:0"`
+	LastError     string     `gorm:"type:text"`
+	NextAttemptAt time.Time  `gorm:"index;not null"`
+	LockedUntil   *time.Time // аренда relay: до этого момента сообщение публикует другой экземпляр
+	SentAt        *time.Time `gorm:"index"`
+	CreatedAt     time.Time
+}
+
+func (Message) TableName() string {
+	return "outbox_messages"
+}
+
+// traceHeaders разбирает TraceContext; у сообщений без него трейс начнётся заново.
+func (m *Message) traceHeaders() map[string]string {
+	headers := map[string]string{}
+	if m.TraceContext != "" {
+		_ = json.Unmarshal([]byte(m.TraceContext), &headers)
+	}
+	return headers
+}
+
+// Event — то, что доменный код кладёт в outbox; Payload сериализуется в JSON.
+type Event struct {
+	Producer string
+	Key      string
+	Payload  interface{}
+}
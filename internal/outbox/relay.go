@@ -0,0 +1,120 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/product-service/internal/metrics"
+	"github.com/ShopOnGO/product-service/internal/tracing"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	publishTimeout  = 10 * time.Second
+	baseBackoff     = time.Second
+	maxBackoff      = 5 * time.Minute
+	retention       = 7 * 24 * time.Hour
+	cleanupInterval = time.Hour
+)
+
+// Relay публикует сообщения outbox в Kafka и повторяет неудачные отправки с экспоненциальной задержкой.
+type Relay struct {
+	repo      *OutboxRepository
+	producers map[string]*kafkaService.KafkaService
+	interval  time.Duration
+	batchSize int
+	lease     time.Duration
+}
+
+// NewRelay: lease — срок аренды сообщения, продлевается перед каждой публикацией пачки,
+// поэтому должен быть больше publishTimeout.
+func NewRelay(repo *OutboxRepository, producers map[string]*kafkaService.KafkaService, interval time.Duration, batchSize int, lease time.Duration) *Relay {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	if lease <= publishTimeout {
+		lease = 3 * publishTimeout
+	}
+	return &Relay{
+		repo:      repo,
+		producers: producers,
+		interval:  interval,
+		batchSize: batchSize,
+		lease:     lease,
+	}
+}
+
+// Run работает до отмены контекста.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(cleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Outbox relay stopped")
+			return
+		case <-ticker.C:
+			r.drain(ctx)
+		case <-cleanup.C:
+			deleted, err := r.repo.DeleteSentBefore(time.Now().Add(-retention))
+			if err != nil {
+				logger.Errorf("Ошибка очистки outbox: %v", err)
+			} else if deleted > 0 {
+				logger.Infof("Удалено отправленных сообщений outbox: %d", deleted)
+			}
+		}
+	}
+}
+
+// drain отправляет пачки, пока они заполняются целиком, чтобы не ждать тика при накопившемся хвосте.
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		sent, err := r.repo.ProcessPending(r.batchSize, r.lease, func(m *Message) error {
+			return r.publish(ctx, m)
+		}, backoff)
+		if err != nil {
+			logger.Errorf("Ошибка отправки outbox: %v", err)
+			return
+		}
+		if sent < r.batchSize {
+			return
+		}
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, m *Message) error {
+	producer := r.producers[m.Producer]
+	if producer == nil {
+		return fmt.Errorf("no kafka producer for %q", m.Producer)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	ctx = tracing.ContextFromMap(ctx, m.traceHeaders())
+	if err := metrics.Produce(ctx, producer, kafka.Message{Key: []byte(m.Key), Value: m.Payload}); err != nil {
+		logger.Warnf("Outbox message %d (%s) not sent, attempt %d: %v", m.ID, m.Key, m.Attempts+1, err)
+		return err
+	}
+	return nil
+}
+
+// backoff: 1s, 2s, 4s, ... но не больше maxBackoff.
+func backoff(attempts uint) time.Duration {
+	if attempts > 16 {
+		return maxBackoff
+	}
+	d := baseBackoff << (attempts - 1)
+	if d > maxBackoff {
+		return maxBackoff
+	}
+	return d
+}
@@ -0,0 +1,166 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ShopOnGO/product-service/internal/tracing"
+	"github.com/ShopOnGO/product-service/pkg/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository struct {
+	Database *db.Db
+}
+
+func NewOutboxRepository(database *db.Db) *OutboxRepository {
+	return &OutboxRepository{
+		Database: database,
+	}
+}
+
+// Enqueue записывает события в outbox внутри переданной транзакции:
+// событие сохраняется тогда и только тогда, когда фиксируется изменение данных.
+func Enqueue(tx *gorm.DB, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	traceContext, err := json.Marshal(tracing.HeadersMap(tx.Statement.Context))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	messages := make([]Message, 0, len(events))
+	for _, event := range events {
+		payload, err := json.Marshal(event.Payload)
+		if err != nil {
+			return fmt.Errorf("marshal outbox event %s: %w", event.Key, err)
+		}
+		messages = append(messages, Message{
+			Producer:      event.Producer,
+			Key:           event.Key,
+			Payload:       payload,
+			TraceContext:  string(traceContext),
+			NextAttemptAt: now,
+		})
+	}
+	return tx.Create(&messages).Error
+}
+
+// ProcessPending берёт в аренду пачку готовых к отправке сообщений и передаёт их в publish.
+// Транзакции короткие: claim отбирает сообщения и сдаёт их в аренду на lease, публикация идёт без
+// транзакции и блокировок строк, а перед каждой публикацией аренда оставшихся сообщений продлевается
+// ещё на lease, так что она не истекает на длинной пачке. Отправленные помечаются SentAt, неудачное
+// откладывается на backoff(attempts). После падения relay сообщения снова доступны, как только истечёт аренда.
+//
+// Доставка at-least-once и без гарантии порядка: если аренда всё же истекла, сообщение может уйти
+// дважды, отложенное сообщение уходит позже следующих за ним, а несколько экземпляров сервиса
+// публикуют разные пачки параллельно. Потребители должны быть идемпотентны и не полагаться на порядок.
+// На первой ошибке пачка прерывается, чтобы не ждать publishTimeout на каждом сообщении при
+// недоступной Kafka; оставшиеся сообщения сразу освобождаются.
+func (repo *OutboxRepository) ProcessPending(limit int, lease time.Duration, publish func(*Message) error, backoff func(attempts uint) time.Duration) (int, error) {
+	messages, err := repo.claim(limit, lease)
+	if err != nil || len(messages) == 0 {
+		return 0, err
+	}
+
+	var sent []uint
+	var failed *Message
+	var publishErr error
+	for i := range messages {
+		if i > 0 {
+			if err := repo.extend(messages[i:], lease); err != nil {
+				publishErr = err
+				break
+			}
+		}
+		if publishErr = publish(&messages[i]); publishErr != nil {
+			failed = &messages[i]
+			break
+		}
+		sent = append(sent, messages[i].ID)
+	}
+
+	err = repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if len(sent) > 0 {
+			if err := tx.Model(&Message{}).Where("id IN ?", sent).Updates(map[string]interface{}{
+				"sent_at":      time.Now(),
+				"attempts":     gorm.Expr("attempts + 1"),
+				"locked_until": nil,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		rest := messages[len(sent):]
+		if failed != nil {
+			attempts := failed.Attempts + 1
+			if err := tx.Model(failed).Updates(map[string]interface{}{
+				"attempts":        attempts,
+				"last_error":      publishErr.Error(),
+				"next_attempt_at": time.Now().Add(backoff(attempts)),
+				"locked_until":    nil,
+			}).Error; err != nil {
+				return err
+			}
+			rest = rest[1:]
+		}
+		if len(rest) == 0 {
+			return nil
+		}
+		return tx.Model(&Message{}).Where("id IN ?", messageIDs(rest)).Update("locked_until", nil).Error
+	})
+	if err == nil && failed == nil && publishErr != nil {
+		err = publishErr
+	}
+	return len(sent), err
+}
+
+// extend продлевает аренду ещё не отправленных сообщений пачки на lease от текущего момента.
+func (repo *OutboxRepository) extend(messages []Message, lease time.Duration) error {
+	return repo.Database.DB.Model(&Message{}).
+		Where("id IN ? AND sent_at IS NULL", messageIDs(messages)).
+		Update("locked_until", time.Now().Add(lease)).Error
+}
+
+func messageIDs(messages []Message) []uint {
+	ids := make([]uint, len(messages))
+	for i := range messages {
+		ids[i] = messages[i].ID
+	}
+	return ids
+}
+
+// claim отбирает до limit готовых к отправке сообщений, не арендованных другим relay, и сдаёт их
+// в аренду на lease. SKIP LOCKED не даёт двум экземплярам взять одни и те же строки одновременно.
+func (repo *OutboxRepository) claim(limit int, lease time.Duration) ([]Message, error) {
+	var messages []Message
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("sent_at IS NULL AND next_attempt_at <= ?", now).
+			Where("locked_until IS NULL OR locked_until <= ?", now).
+			Order("id").
+			Limit(limit).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		return tx.Model(&Message{}).Where("id IN ?", messageIDs(messages)).Update("locked_until", now.Add(lease)).Error
+	})
+	return messages, err
+}
+
+// DeleteSentBefore удаляет отправленные сообщения старше before.
+func (repo *OutboxRepository) DeleteSentBefore(before time.Time) (int64, error) {
+	result := repo.Database.DB.
+		Where("sent_at IS NOT NULL AND sent_at < ?", before).
+		Delete(&Message{})
+	return result.RowsAffected, result.Error
+}
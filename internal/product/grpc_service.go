@@ -4,6 +4,13 @@ import (
 	"context"
 
 	pb "github.com/ShopOnGO/product-proto/pkg/product"
+	"github.com/ShopOnGO/product-service/internal/brand"
+	"github.com/ShopOnGO/product-service/internal/category"
+	"github.com/ShopOnGO/product-service/internal/productVariant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type GrpcProductService struct {
@@ -21,27 +28,14 @@ func (g *GrpcProductService) GetProductsByIDs(ctx context.Context, req *pb.GetPr
 		productIDs[i] = uint(id)
 	}
 
-	products, err := g.productSvc.GetProductsByIDs(productIDs)
+	products, err := g.productSvc.GetProductsByIDs(ctx, productIDs)
 	if err != nil {
 		return nil, err
 	}
 
 	grpcProducts := make([]*pb.Product, len(products))
-	for i, p := range products {
-		grpcProducts[i] = &pb.Product{
-			Id:           uint64(p.ID),
-			Name:         p.Name,
-			Description:  p.Description,
-			Rating:       p.Rating.InexactFloat64(),
-			ReviewCount:  uint32(p.ReviewCount),
-			RatingSum:    uint32(p.RatingSum),
-			QuestionCount:uint32(p.QuestionCount),
-			IsActive:     p.IsActive,
-			CategoryId:   uint32(p.CategoryID),
-			BrandId:      uint32(p.BrandID),
-			ImageUrls:    p.ImageURLs,
-			VideoUrls:    p.VideoURLs,
-		}
+	for i := range products {
+		grpcProducts[i] = productToProto(&products[i])
 	}
 
 	return &pb.GetProductsByIDsResponse{
@@ -49,3 +43,145 @@ func (g *GrpcProductService) GetProductsByIDs(ctx context.Context, req *pb.GetPr
 	}, nil
 }
 
+func (g *GrpcProductService) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb.GetProductResponse, error) {
+	if req.ProductId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "product_id is required")
+	}
+	product, err := g.productSvc.GetProductByID(ctx, uint(req.ProductId))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetProductResponse{Product: productToProto(product)}, nil
+}
+
+func (g *GrpcProductService) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	if req.CategoryId == 0 && req.BrandId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "category_id or brand_id is required")
+	}
+
+	page, err := g.productSvc.ListProducts(ctx, ProductListQuery{
+		Page:       int(req.Page),
+		PageSize:   int(req.PageSize),
+		Cursor:     req.Cursor,
+		Sort:       req.Sort,
+		Order:      req.Order,
+		CategoryID: uint(req.CategoryId),
+		BrandID:    uint(req.BrandId),
+		IsActive:   req.IsActive,
+		InStock:    req.InStock,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListProductsResponse{
+		Total:      page.Total,
+		Page:       uint32(page.Page),
+		PageSize:   uint32(page.PageSize),
+		NextCursor: page.NextCursor,
+	}
+	for i := range page.Items {
+		resp.Products = append(resp.Products, productToProto(&page.Items[i]))
+	}
+	return resp, nil
+}
+
+func (g *GrpcProductService) SearchProducts(ctx context.Context, req *pb.SearchProductsRequest) (*pb.SearchProductsResponse, error) {
+	page, err := g.productSvc.SearchProducts(ctx, ProductSearchQuery{
+		Q: req.Q,
+		ProductListQuery: ProductListQuery{
+			Page:       int(req.Page),
+			PageSize:   int(req.PageSize),
+			Cursor:     req.Cursor,
+			Sort:       req.Sort,
+			Order:      req.Order,
+			CategoryID: uint(req.CategoryId),
+			BrandID:    uint(req.BrandId),
+			InStock:    req.InStock,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.SearchProductsResponse{
+		Total:      page.Total,
+		Page:       uint32(page.Page),
+		PageSize:   uint32(page.PageSize),
+		NextCursor: page.NextCursor,
+	}
+	for i := range page.Items {
+		item := &page.Items[i]
+		resp.Hits = append(resp.Hits, &pb.SearchHit{
+			Product:              productToProto(&item.Product),
+			Relevance:            item.Relevance,
+			NameHighlight:        item.Highlight.Name,
+			DescriptionHighlight: item.Highlight.Description,
+		})
+	}
+	return resp, nil
+}
+
+func (g *GrpcProductService) StreamActiveProducts(req *pb.StreamActiveProductsRequest, stream grpc.ServerStreamingServer[pb.Product]) error {
+	return g.productSvc.StreamActiveProducts(stream.Context(), int(req.BatchSize), func(p *Product) error {
+		return stream.Send(productToProto(p))
+	})
+}
+
+// productToProto переводит продукт в proto-сообщение; категория, бренд и варианты
+// заполняются, только если они загружены.
+func productToProto(p *Product) *pb.Product {
+	msg := &pb.Product{
+		Id:            uint64(p.ID),
+		Name:          p.Name,
+		Description:   p.Description,
+		Rating:        p.Rating.InexactFloat64(),
+		ReviewCount:   uint32(p.ReviewCount),
+		RatingSum:     uint32(p.RatingSum),
+		QuestionCount: uint32(p.QuestionCount),
+		IsActive:      p.IsActive,
+		CategoryId:    uint32(p.CategoryID),
+		BrandId:       uint32(p.BrandID),
+		ImageUrls:     p.ImageURLs,
+		VideoUrls:     p.VideoURLs,
+		Material:      p.Material,
+		SellerId:      uint64(p.SellerID),
+		CreatedAt:     timestamppb.New(p.CreatedAt),
+		UpdatedAt:     timestamppb.New(p.UpdatedAt),
+		Category:      categoryToProto(&p.Category),
+		Brand:         brandToProto(&p.Brand),
+	}
+	for i := range p.Variants {
+		msg.Variants = append(msg.Variants, productVariant.VariantToProto(&p.Variants[i]))
+	}
+	return msg
+}
+
+func categoryToProto(c *category.Category) *pb.Category {
+	if c.ID == 0 {
+		return nil
+	}
+	msg := &pb.Category{
+		Id:          uint64(c.ID),
+		Name:        c.Name,
+		Description: c.Description,
+		ImageUrl:    c.ImageURL,
+	}
+	if c.ParentCategoryID != nil {
+		msg.ParentCategoryId = uint64(*c.ParentCategoryID)
+	}
+	return msg
+}
+
+func brandToProto(b *brand.Brand) *pb.Brand {
+	if b.ID == 0 {
+		return nil
+	}
+	return &pb.Brand{
+		Id:          uint64(b.ID),
+		Name:        b.Name,
+		Description: b.Description,
+		Logo:        b.Logo,
+		VideoUrl:    b.VideoURL,
+	}
+}
@@ -1,16 +1,21 @@
 package product
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
+	"github.com/ShopOnGO/product-service/internal/attribute"
+	"github.com/ShopOnGO/product-service/internal/auth"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type ProductHandlerDeps struct {
 	ProductSvc *ProductService
 	Kafka      *kafkaService.KafkaService
+	Auth       gin.HandlerFunc // проверка JWT для изменяющих запросов
 }
 
 type ProductHandler struct {
@@ -27,31 +32,143 @@ func NewProductHandler(router *gin.Engine, deps ProductHandlerDeps) *ProductHand
 	productGroup := router.Group("/product-service/products")
 	{
 		productGroup.GET("/", handler.GetProducts)
+		productGroup.GET("/search", handler.SearchProducts)
+		productGroup.GET("/facets", handler.GetFacets)
 		productGroup.GET("/:id", handler.GetProductByID)
-		productGroup.POST("/", handler.CreateProduct)
-		productGroup.PUT("/:id", handler.UpdateProduct)
-		productGroup.DELETE("/:id", handler.DeleteProduct)
+		productGroup.POST("/", deps.Auth, handler.CreateProduct)
+		productGroup.PUT("/:id", deps.Auth, handler.UpdateProduct)
+		productGroup.DELETE("/:id", deps.Auth, handler.DeleteProduct)
 	}
 
 	return handler
 }
 
-// GetProducts получает все продукты
-// @Summary Получение всех продуктов
-// @Description Возвращает список всех продуктов
+// GetProducts получает страницу продуктов
+// @Summary Листинг продуктов
+// @Description Возвращает страницу продуктов с фильтрами, сортировкой и пагинацией (постраничной или по курсору)
 // @Tags Продукты
 // @Accept json
 // @Produce json
-// @Success 200 {array} Product
+// @Param page query int false "Номер страницы (с 1)"
+// @Param page_size query int false "Размер страницы (по умолчанию 20, максимум 100)"
+// @Param cursor query string false "Курсор следующей страницы (приоритетнее page)"
+// @Param sort query string false "Поле сортировки: price, rating, created_at, review_count"
+// @Param order query string false "Направление сортировки: asc, desc"
+// @Param category_id query int false "ID категории (включая подкатегории)"
+// @Param brand_id query int false "ID бренда"
+// @Param is_active query bool false "Только активные/неактивные продукты"
+// @Param min_price query string false "Минимальная итоговая цена варианта (со скидками и акциями)"
+// @Param max_price query string false "Максимальная итоговая цена варианта (со скидками и акциями)"
+// @Param in_stock query bool false "Только продукты с вариантами в наличии"
+// @Param color query string false "Цвет варианта"
+// @Param size query string false "Размер варианта"
+// @Param material query string false "Материал продукта"
+// @Success 200 {object} ProductPage
+// @Failure 400 {object} map[string]string "Неверные параметры запроса"
 // @Failure 500 {object} map[string]string "Ошибка получения продуктов"
 // @Router /products [get]
 func (h *ProductHandler) GetProducts(c *gin.Context) {
-	products, err := h.ProductSvc.GetAllProducts()
+	var query ProductListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	page, err := h.ProductSvc.ListProducts(c.Request.Context(), query)
 	if err != nil {
+		if errors.Is(err, ErrInvalidCursor) || errors.Is(err, ErrInvalidSort) || errors.Is(err, ErrInvalidPriceSpan) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch products"})
 		return
 	}
-	c.JSON(http.StatusOK, products)
+	c.JSON(http.StatusOK, page)
+}
+
+// SearchProducts ищет продукты по тексту
+// @Summary Поиск продуктов
+// @Description Полнотекстовый поиск (русский и английский) по названию, описанию, материалу, бренду и категории
+// @Description с нечётким совпадением названия при опечатках. Фильтры и пагинация — как у листинга.
+// @Tags Продукты
+// @Accept json
+// @Produce json
+// @Param q query string true "Поисковый запрос (до 200 символов)"
+// @Param page query int false "Номер страницы (с 1)"
+// @Param page_size query int false "Размер страницы (по умолчанию 20, максимум 100)"
+// @Param cursor query string false "Курсор следующей страницы (приоритетнее page)"
+// @Param sort query string false "Поле сортировки: relevance (по умолчанию), price, rating, created_at, review_count"
+// @Param order query string false "Направление сортировки: asc, desc"
+// @Param category_id query int false "ID категории (включая подкатегории)"
+// @Param brand_id query int false "ID бренда"
+// @Param is_active query bool false "Только активные/неактивные продукты"
+// @Param min_price query string false "Минимальная итоговая цена варианта (со скидками и акциями)"
+// @Param max_price query string false "Максимальная итоговая цена варианта (со скидками и акциями)"
+// @Param in_stock query bool false "Только продукты с вариантами в наличии"
+// @Param color query string false "Цвет варианта"
+// @Param size query string false "Размер варианта"
+// @Param material query string false "Материал продукта"
+// @Success 200 {object} ProductSearchPage
+// @Failure 400 {object} map[string]string "Неверные параметры запроса"
+// @Failure 500 {object} map[string]string "Ошибка поиска"
+// @Router /products/search [get]
+func (h *ProductHandler) SearchProducts(c *gin.Context) {
+	var query ProductSearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	page, err := h.ProductSvc.SearchProducts(c.Request.Context(), query)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSearch) || errors.Is(err, ErrInvalidCursor) || errors.Is(err, ErrInvalidSort) || errors.Is(err, ErrInvalidPriceSpan) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search products"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetFacets считает фасеты для фильтров
+// @Summary Фасеты продуктов
+// @Description Число продуктов по брендам, категориям, цветам, размерам, материалам и ценовым диапазонам
+// @Description для текущих фильтров. Каждый фасет считается без собственного фильтра.
+// @Tags Продукты
+// @Produce json
+// @Param q query string false "Поисковый запрос — фасеты считаются по выдаче поиска"
+// @Param category_id query int false "ID категории (включая подкатегории)"
+// @Param brand_id query int false "ID бренда"
+// @Param is_active query bool false "Только активные/неактивные продукты"
+// @Param min_price query string false "Минимальная итоговая цена варианта (со скидками и акциями)"
+// @Param max_price query string false "Максимальная итоговая цена варианта (со скидками и акциями)"
+// @Param in_stock query bool false "Только продукты с вариантами в наличии"
+// @Param color query string false "Цвет варианта"
+// @Param size query string false "Размер варианта"
+// @Param material query string false "Материал продукта"
+// @Param price_buckets query string false "Границы ценовых диапазонов через запятую (по умолчанию 500,1000,2500,5000,10000,25000)"
+// @Success 200 {object} ProductFacets
+// @Failure 400 {object} map[string]string "Неверные параметры запроса"
+// @Failure 500 {object} map[string]string "Ошибка подсчёта фасетов"
+// @Router /products/facets [get]
+func (h *ProductHandler) GetFacets(c *gin.Context) {
+	var query ProductFacetsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	facets, err := h.ProductSvc.GetFacets(c.Request.Context(), query)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSearch) || errors.Is(err, ErrInvalidPriceSpan) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count facets"})
+		return
+	}
+	c.JSON(http.StatusOK, facets)
 }
 
 // GetProductByID получает продукт по ID
@@ -72,7 +189,7 @@ func (h *ProductHandler) GetProductByID(c *gin.Context) {
 		return
 	}
 
-	product, err := h.ProductSvc.GetProductByID(uint(id))
+	product, err := h.ProductSvc.GetProductByID(c.Request.Context(), uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -91,6 +208,9 @@ func (h *ProductHandler) GetProductByID(c *gin.Context) {
 // @Success 201 {object} Product
 // @Failure 400 {object} map[string]string "Неверное тело запроса"
 // @Failure 500 {object} map[string]string "Ошибка при создании продукта"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Продукт принадлежит другому продавцу"
+// @Security ApiKeyAuth
 // @Router /products [post]
 func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	var input Product
@@ -99,9 +219,9 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.ProductSvc.CreateProduct(&input)
+	product, err := h.ProductSvc.CreateProduct(c.Request.Context(), &input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create product"})
+		writeProductError(c, err)
 		return
 	}
 
@@ -122,16 +242,20 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 
 // UpdateProduct обновляет данные продукта
 // @Summary Обновление данных продукта
-// @Description Обновляет информацию о продукте по его ID
+// @Description Обновляет информацию о продукте по его ID. При смене категории атрибуты вариантов
+// @Description перепроверяются по её определениям; несовместимая категория отклоняется
 // @Tags Продукты
 // @Accept json
 // @Produce json
 // @Param id path int true "ID продукта"
 // @Param product body Product true "Данные для обновления продукта"
 // @Success 200 {object} Product
-// @Failure 400 {object} map[string]string "Неверное тело запроса"
+// @Failure 400 {object} map[string]string "Неверное тело запроса или атрибуты вариантов не подходят новой категории"
 // @Failure 404 {object} map[string]string "Продукт не найден"
 // @Failure 500 {object} map[string]string "Ошибка при обновлении продукта"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Продукт принадлежит другому продавцу"
+// @Security ApiKeyAuth
 // @Router /products/{id} [put]
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -146,9 +270,9 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.ProductSvc.UpdateProduct(uint(id), &updated)
+	product, err := h.ProductSvc.UpdateProduct(c.Request.Context(), uint(id), &updated)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeProductError(c, err)
 		return
 	}
 
@@ -169,14 +293,18 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 
 // DeleteProduct удаляет продукт
 // @Summary Удаление продукта
-// @Description Удаляет продукт по его ID
+// @Description Мягко удаляет продукт по его ID вместе со всеми вариантами
 // @Tags Продукты
 // @Accept json
 // @Produce json
 // @Param id path int true "ID продукта"
 // @Success 200 {object} map[string]string "Продукт удалён"
 // @Failure 400 {object} map[string]string "Неверный ID продукта"
+// @Failure 404 {object} map[string]string "Продукт не найден"
 // @Failure 500 {object} map[string]string "Ошибка при удалении продукта"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Продукт принадлежит другому продавцу"
+// @Security ApiKeyAuth
 // @Router /products/{id} [delete]
 func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -185,14 +313,34 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	if err := h.ProductSvc.DeleteProduct(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if _, err := h.ProductSvc.DeleteProduct(c.Request.Context(), uint(id)); err != nil {
+		writeProductError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "product deleted"})
 }
 
+// writeProductError переводит ошибки изменения продукта в HTTP-статусы.
+func writeProductError(c *gin.Context, err error) {
+	if status, ok := attribute.ErrorStatus(err); ok {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	switch {
+	case errors.Is(err, ErrInvalidProduct):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrUnauthenticated):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 // sendNotification — вспомогательный метод для отправки уведомлений в Kafka
 // func (h *ProductHandler) sendNotification(
 // 	c *gin.Context,
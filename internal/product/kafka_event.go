@@ -3,15 +3,18 @@ package product
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 
-	"github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/product-service/internal/auth"
+	"github.com/ShopOnGO/product-service/internal/outbox"
 	"github.com/ShopOnGO/product-service/internal/productVariant"
 )
 
-func HandleProductEvent(msg []byte, key string, productSvc *ProductService, productVariantSvc *productVariant.ProductVariantService, kafkaProducer *kafkaService.KafkaService) error {
+// productsProducer — ключ продюсера топика продуктов, который слушают Search и Media.
+const productsProducer = "products"
+
+func HandleProductEvent(ctx context.Context, msg []byte, key string, productSvc *ProductService, productVariantSvc *productVariant.ProductVariantService) error {
 	logger.Infof("Получено сообщение: %s", string(msg))
 
 	var base BaseProductEvent
@@ -19,13 +22,13 @@ func HandleProductEvent(msg []byte, key string, productSvc *ProductService, prod
 		return fmt.Errorf("ошибка десериализации базового сообщения: %w", err)
 	}
 
-	logger.Infof("Action = %q, key = %s", base.Action, key)
+	logger.Infof("Action = %q, key = %s, event_id = %q", base.Action, key, base.EventID)
 
-	eventHandlers := map[string]func([]byte, *ProductService, *productVariant.ProductVariantService, *kafkaService.KafkaService) error{
-		"create": HandleCreateProductEvent,
+	eventHandlers := map[string]func(context.Context, []byte, *ProductService, *productVariant.ProductVariantService) error{
+		"create":       HandleCreateProductEvent,
 		"media-stored": HandleMediaEvent,
-		// "update": HandleUpdateProductEvent,
-		// "delete": HandleDeleteProductEvent,
+		"update":       HandleUpdateProductEvent,
+		"delete":       HandleDeleteProductEvent,
 	}
 
 	handler, exists := eventHandlers[base.Action]
@@ -33,10 +36,12 @@ func HandleProductEvent(msg []byte, key string, productSvc *ProductService, prod
 		return fmt.Errorf("неизвестное действие для продукта: %s", base.Action)
 	}
 
-	return handler(msg, productSvc, productVariantSvc, kafkaProducer)
+	return handler(ctx, msg, productSvc, productVariantSvc)
 }
 
-func HandleCreateProductEvent(msg []byte, productSvc *ProductService, productVariantSvc *productVariant.ProductVariantService, kafkaProducer *kafkaService.KafkaService) error {
+// HandleCreateProductEvent создаёт продукт вместе с вариантами в одной транзакции;
+// событие product-created отправит outbox relay после фиксации.
+func HandleCreateProductEvent(ctx context.Context, msg []byte, productSvc *ProductService, productVariantSvc *productVariant.ProductVariantService) error {
 	var base BaseProductEvent
 	if err := json.Unmarshal(msg, &base); err != nil {
 		return fmt.Errorf("ошибка десериализации базового сообщения: %w", err)
@@ -44,6 +49,7 @@ func HandleCreateProductEvent(msg []byte, productSvc *ProductService, productVar
 
 	userID := base.UserID
 	logger.Infof("Создание продукта пользователем %d", userID)
+	ctx = sellerContext(ctx, userID)
 
 	event := base.Product
 	logger.Infof("Получены данные для создания продукта: name=%q, category_id=%d, brand_id=%d",
@@ -58,81 +64,80 @@ func HandleCreateProductEvent(msg []byte, productSvc *ProductService, productVar
 		BrandID:     event.BrandID,
 	}
 
-	createdProduct, err := productSvc.CreateProduct(newProduct)
-	if err != nil {
-		logger.Errorf("Ошибка при создании отзыва: %v", err)
-		return err
-	}
-	logger.Infof("Продукт успешно создан: %+v", createdProduct)
-
-	var createdVariants []productVariant.ProductVariant
-
 	for _, variantReq := range event.Variants {
 		logger.Infof("Обрабатываем вариант: %+v", variantReq)
-		variant := &productVariant.ProductVariant{
-			ProductID: createdProduct.ID,
-			SKU:       variantReq.SKU,
-			Price:     variantReq.Price,
-			Discount:  variantReq.Discount,
-			Sizes:     variantReq.Sizes,
-			Colors:    variantReq.Colors,
-			Stock:     variantReq.Stock,
-			ImageURLs: variantReq.ImageURLs,
-			IsActive:  true,
-    	}
-
-		createdVariant, err := productVariantSvc.CreateProductVariant(variant)
-		if err != nil {
-			logger.Errorf("Ошибка при создании варианта: %v", err)
-			return err
+		variant := productVariant.ProductVariant{
+			SKU:        variantReq.SKU,
+			Price:      variantReq.Price,
+			Discount:   variantReq.Discount,
+			Sizes:      variantReq.Sizes,
+			Colors:     variantReq.Colors,
+			Stock:      variantReq.Stock,
+			ImageURLs:  variantReq.ImageURLs,
+			IsActive:   true,
+			Attributes: variantReq.Attributes,
 		}
-		if len(createdVariant.ImageURLs) == 0 {
-			createdVariant.ImageURLs = variantReq.ImageURLs
+		if err := productVariantSvc.ValidateNewVariant(ctx, &variant); err != nil {
+			logger.Errorf("Вариант не прошёл проверку: %v", err)
+			return err
 		}
-		createdVariants = append(createdVariants, *createdVariant)
+		newProduct.Variants = append(newProduct.Variants, variant)
 	}
-	var variantsForEvent []*ProductVariantForEvent
-	for _, v := range createdVariants {
-		variantsForEvent = append(variantsForEvent, ConvertVariantToEvent(&v))
+
+	createdProduct, err := productSvc.CreateProduct(ctx, newProduct)
+	if err != nil {
+		logger.Errorf("Ошибка при создании продукта: %v", err)
+		return err
 	}
+	logger.Infof("Продукт успешно создан: ID=%d, вариантов: %d", createdProduct.ID, len(createdProduct.Variants))
+	return nil
+}
 
-	eventForMediaAndSearch := ProductCreatedEventForMediaAndSearch{
-		Action:    		"create",
-		ProductID: 		createdProduct.ID,
-		Name:        	event.Name,
-		Description: 	event.Description,
-		Material:    	event.Material,
-		Rating:      	event.Rating.InexactFloat64(),
-		ReviewCount: 	event.ReviewCount,
-		IsActive:    	event.IsActive,
-		CategoryID:  	event.CategoryID,
-		BrandID:     	event.BrandID,
-		Variants:   	variantsForEvent,
+// HandleUpdateProductEvent частично обновляет продукт: меняются только переданные поля.
+func HandleUpdateProductEvent(ctx context.Context, msg []byte, productSvc *ProductService, productVariantSvc *productVariant.ProductVariantService) error {
+	var base BaseProductUpdateEvent
+	if err := json.Unmarshal(msg, &base); err != nil {
+		return fmt.Errorf("ошибка десериализации события обновления продукта: %w", err)
+	}
+	if base.ProductID == 0 {
+		return fmt.Errorf("%w: product_id is required", ErrInvalidProduct)
 	}
 
-	value, err := json.Marshal(eventForMediaAndSearch)
+	logger.Infof("Обновление продукта ID %d пользователем %d", base.ProductID, base.UserID)
+	ctx = sellerContext(ctx, base.UserID)
+
+	updated, err := productSvc.PatchProduct(ctx, base.ProductID, base.Product)
 	if err != nil {
-		logger.Errorf("Ошибка сериализации события product-created: %v", err)
+		logger.Errorf("Ошибка при обновлении продукта: %v", err)
 		return err
 	}
+	logger.Infof("Продукт ID %d успешно обновлён", updated.ID)
+	return nil
+}
 
-	ctx := context.Background()
-
-	if kafkaProducer == nil {
-		logger.Errorf("kafkaProducer is nil, cannot send event")
-		return errors.New("kafkaProducer is nil")
+// HandleDeleteProductEvent мягко удаляет продукт вместе с вариантами.
+func HandleDeleteProductEvent(ctx context.Context, msg []byte, productSvc *ProductService, productVariantSvc *productVariant.ProductVariantService) error {
+	var base BaseProductDeleteEvent
+	if err := json.Unmarshal(msg, &base); err != nil {
+		return fmt.Errorf("ошибка десериализации события удаления продукта: %w", err)
+	}
+	if base.ProductID == 0 {
+		return fmt.Errorf("%w: product_id is required", ErrInvalidProduct)
 	}
 
-	if err := kafkaProducer.Produce(ctx, []byte("product-created"), value); err != nil {
-		logger.Errorf("Ошибка отправки сообщения в Kafka: %v", err)
+	logger.Infof("Удаление продукта ID %d пользователем %d", base.ProductID, base.UserID)
+	ctx = sellerContext(ctx, base.UserID)
+
+	deleted, err := productSvc.DeleteProduct(ctx, base.ProductID)
+	if err != nil {
+		logger.Errorf("Ошибка при удалении продукта: %v", err)
 		return err
 	}
-
+	logger.Infof("Продукт ID %d удалён вместе с %d вариантами", deleted.ID, len(deleted.Variants))
 	return nil
 }
 
-
-func HandleMediaEvent(msg []byte, productSvc *ProductService, productVariantSvc *productVariant.ProductVariantService, kafkaProducer *kafkaService.KafkaService) error {
+func HandleMediaEvent(ctx context.Context, msg []byte, productSvc *ProductService, productVariantSvc *productVariant.ProductVariantService) error {
 	var event MediaUpdateEvent
 	if err := json.Unmarshal(msg, &event); err != nil {
 		return fmt.Errorf("ошибка десериализации события обновления медиа: %w", err)
@@ -141,7 +146,7 @@ func HandleMediaEvent(msg []byte, productSvc *ProductService, productVariantSvc
 	logger.Infof("Обновление медиа для продукта ID %d: images=%v, video=%q",
 		event.ProductID, event.ImageURLs, event.VideoURLs)
 
-	if err := productSvc.UpdateProductMedia(event.ProductID, event.ImageURLs, event.VideoURLs); err != nil {
+	if err := productSvc.UpdateProductMedia(ctx, event.ProductID, event.ImageURLs, event.VideoURLs); err != nil {
 		logger.Errorf("Ошибка при обновлении медиа: %v", err)
 		return err
 	}
@@ -150,21 +155,54 @@ func HandleMediaEvent(msg []byte, productSvc *ProductService, productVariantSvc
 	return nil
 }
 
+// sellerContext: изменения из Kafka выполняются от имени продавца из user_id события,
+// поэтому к ним применяются те же проверки владельца, что и к REST.
+func sellerContext(ctx context.Context, userID int64) context.Context {
+	if userID <= 0 {
+		return ctx
+	}
+	return auth.WithActor(ctx, auth.Actor{UserID: uint(userID), Role: auth.RoleSeller})
+}
+
+func productCreatedEvent(p *Product) outbox.Event {
+	return outbox.Event{Producer: productsProducer, Key: "product-created", Payload: newProductEventForMediaAndSearch("create", p)}
+}
 
-func ConvertVariantToEvent(v *productVariant.ProductVariant) *ProductVariantForEvent {
-    return &ProductVariantForEvent{
-        VariantID:     v.ID,
-        SKU:           v.SKU,
-        Price:         v.Price.InexactFloat64(),
-        Discount:      v.Discount.InexactFloat64(),
-        Sizes:         v.Sizes,
-        Colors:        v.Colors,
-        Stock:         v.Stock,
-        Barcode:       v.Barcode,
-        Dimensions:    v.Dimensions,
-        Images:        v.ImageURLs,
-        MinOrder:      v.MinOrder,
-        IsActive:      v.IsActive,
-        ReservedStock: v.ReservedStock,
-    }
-}
\ No newline at end of file
+func productUpdatedEvent(p *Product) outbox.Event {
+	return outbox.Event{Producer: productsProducer, Key: "product-updated", Payload: newProductEventForMediaAndSearch("update", p)}
+}
+
+func productDeletedEvent(p *Product) outbox.Event {
+	variantIDs := make([]uint, 0, len(p.Variants))
+	for _, v := range p.Variants {
+		variantIDs = append(variantIDs, v.ID)
+	}
+	return outbox.Event{Producer: productsProducer, Key: "product-deleted", Payload: ProductDeletedEventForMediaAndSearch{
+		Action:     "delete",
+		ProductID:  p.ID,
+		ImageURLs:  p.ImageURLs,
+		VideoURLs:  p.VideoURLs,
+		VariantIDs: variantIDs,
+	}}
+}
+
+func newProductEventForMediaAndSearch(action string, p *Product) ProductCreatedEventForMediaAndSearch {
+	variantsForEvent := make([]*productVariant.ProductVariantForEvent, 0, len(p.Variants))
+	for i := range p.Variants {
+		variantsForEvent = append(variantsForEvent, productVariant.ConvertVariantToEvent(&p.Variants[i]))
+	}
+
+	return ProductCreatedEventForMediaAndSearch{
+		Action:      action,
+		ProductID:   p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Material:    p.Material,
+		Rating:      p.Rating.InexactFloat64(),
+		ReviewCount: p.ReviewCount,
+		IsActive:    p.IsActive,
+		CategoryID:  p.CategoryID,
+		BrandID:     p.BrandID,
+		Variants:    variantsForEvent,
+	}
+}
@@ -0,0 +1,328 @@
+package product
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	defaultSort     = "created_at"
+
+	defaultSearchSort = "relevance"
+	maxSearchLength   = 200
+
+	maxPriceBuckets = 20
+)
+
+// defaultPriceEdges — границы ценовых фасетов, если price_buckets не задан.
+var defaultPriceEdges = []decimal.Decimal{
+	decimal.NewFromInt(500),
+	decimal.NewFromInt(1000),
+	decimal.NewFromInt(2500),
+	decimal.NewFromInt(5000),
+	decimal.NewFromInt(10000),
+	decimal.NewFromInt(25000),
+}
+
+var (
+	ErrInvalidCursor    = errors.New("invalid cursor")
+	ErrInvalidSort      = errors.New("invalid sort field")
+	ErrInvalidPriceSpan = errors.New("invalid price range")
+	ErrInvalidSearch    = errors.New("invalid search query")
+)
+
+// minVariantPriceExpr — минимальная итоговая цена (со скидками и акциями) среди неудалённых вариантов продукта.
+const minVariantPriceExpr = `COALESCE((SELECT MIN(pv.effective_price) FROM product_variants pv WHERE pv.product_id = products.id AND pv.deleted_at IS NULL), 0)`
+
+// sortSpec описывает ключ сортировки: SQL-выражение, тип значения курсора
+// и способ получить это значение из уже загруженного продукта.
+type sortSpec struct {
+	name  string
+	expr  string
+	cast  string
+	value func(p *Product) string
+}
+
+var productSorts = map[string]sortSpec{
+	"created_at": {
+		name:  "created_at",
+		expr:  "products.created_at",
+		cast:  "timestamptz",
+		value: func(p *Product) string { return p.CreatedAt.Format(time.RFC3339Nano) },
+	},
+	"rating": {
+		name:  "rating",
+		expr:  "products.rating",
+		cast:  "numeric",
+		value: func(p *Product) string { return p.Rating.String() },
+	},
+	"review_count": {
+		name:  "review_count",
+		expr:  "products.review_count",
+		cast:  "bigint",
+		value: func(p *Product) string { return strconv.FormatUint(uint64(p.ReviewCount), 10) },
+	},
+	"price": {
+		name:  "price",
+		expr:  minVariantPriceExpr,
+		cast:  "numeric",
+		value: func(p *Product) string { return minVariantPrice(p).String() },
+	},
+}
+
+// searchSorts — ключи сортировки поиска: те же, что у листинга, плюс релевантность.
+// products.search_rank есть только в подзапросе поиска, поэтому в листинге relevance недоступна.
+var searchSorts = func() map[string]sortSpec {
+	sorts := map[string]sortSpec{
+		"relevance": {
+			name:  "relevance",
+			expr:  "products.search_rank",
+			cast:  "float8",
+			value: func(p *Product) string { return strconv.FormatFloat(p.SearchRank, 'g', -1, 64) },
+		},
+	}
+	for name, spec := range productSorts {
+		sorts[name] = spec
+	}
+	return sorts
+}()
+
+// minVariantPrice считает то же, что и minVariantPriceExpr, по предзагруженным вариантам.
+func minVariantPrice(p *Product) decimal.Decimal {
+	if len(p.Variants) == 0 {
+		return decimal.Zero
+	}
+	lowest := p.Variants[0].EffectivePrice
+	for _, v := range p.Variants[1:] {
+		if v.EffectivePrice.LessThan(lowest) {
+			lowest = v.EffectivePrice
+		}
+	}
+	return lowest
+}
+
+// productCursor — позиция в keyset-пагинации: значение ключа сортировки и ID последнего продукта.
+type productCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+func encodeCursor(c productCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (*productCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c productCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// productListParams — провалидированные параметры листинга для репозитория.
+type productListParams struct {
+	sort     sortSpec
+	desc     bool
+	page     int
+	pageSize int
+	cursor   *productCursor
+
+	categoryID uint
+	brandID    uint
+	isActive   *bool
+	minPrice   *decimal.Decimal
+	maxPrice   *decimal.Decimal
+	inStock    bool
+	color      string
+	size       string
+	material   string
+
+	search string // полнотекстовый запрос; пусто — обычный листинг
+}
+
+func newProductListParams(q ProductListQuery) (*productListParams, error) {
+	return newListParams(q, productSorts, defaultSort)
+}
+
+// newProductSearchParams добавляет к параметрам листинга поисковый запрос; по умолчанию сортирует по релевантности.
+func newProductSearchParams(q ProductSearchQuery) (*productListParams, error) {
+	term := strings.TrimSpace(q.Q)
+	if term == "" || utf8.RuneCountInString(term) > maxSearchLength {
+		return nil, fmt.Errorf("%w: q must be 1 to %d characters", ErrInvalidSearch, maxSearchLength)
+	}
+	p, err := newListParams(q.ProductListQuery, searchSorts, defaultSearchSort)
+	if err != nil {
+		return nil, err
+	}
+	p.search = term
+	return p, nil
+}
+
+// productFacetParams — фильтры, по которым считаются фасеты, и границы ценовых диапазонов.
+type productFacetParams struct {
+	filters    *productListParams
+	priceEdges []decimal.Decimal
+}
+
+func newProductFacetParams(q ProductFacetsQuery) (*productFacetParams, error) {
+	// Сортировка и курсор фасетам не нужны: листинговые значения по умолчанию заведомо корректны.
+	q.Sort, q.Order, q.Cursor = "", "", ""
+
+	var (
+		filters *productListParams
+		err     error
+	)
+	if strings.TrimSpace(q.Q) != "" {
+		filters, err = newProductSearchParams(ProductSearchQuery{Q: q.Q, ProductListQuery: q.ProductListQuery})
+	} else {
+		filters, err = newProductListParams(q.ProductListQuery)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	edges, err := parsePriceEdges(q.PriceBuckets)
+	if err != nil {
+		return nil, err
+	}
+	return &productFacetParams{filters: filters, priceEdges: edges}, nil
+}
+
+// without возвращает копию фильтров, в которой change сбросил фильтр считаемого фасета.
+func (p *productFacetParams) without(change func(f *productListParams)) *productListParams {
+	f := *p.filters
+	change(&f)
+	return &f
+}
+
+func parsePriceEdges(s string) ([]decimal.Decimal, error) {
+	if strings.TrimSpace(s) == "" {
+		return defaultPriceEdges, nil
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) > maxPriceBuckets {
+		return nil, fmt.Errorf("%w: at most %d price buckets", ErrInvalidPriceSpan, maxPriceBuckets)
+	}
+	edges := make([]decimal.Decimal, 0, len(parts))
+	for _, part := range parts {
+		edge, err := decimal.NewFromString(strings.TrimSpace(part))
+		if err != nil || !edge.IsPositive() {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPriceSpan, part)
+		}
+		if len(edges) > 0 && !edge.GreaterThan(edges[len(edges)-1]) {
+			return nil, fmt.Errorf("%w: price_buckets must be ascending", ErrInvalidPriceSpan)
+		}
+		edges = append(edges, edge)
+	}
+	return edges, nil
+}
+
+// normalizeFacetValue приводит значение фильтра к виду, в котором фасеты группируют значения.
+func normalizeFacetValue(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+func newListParams(q ProductListQuery, sorts map[string]sortSpec, fallbackSort string) (*productListParams, error) {
+	p := &productListParams{
+		page:       q.Page,
+		pageSize:   q.PageSize,
+		categoryID: q.CategoryID,
+		brandID:    q.BrandID,
+		isActive:   q.IsActive,
+		inStock:    q.InStock,
+		color:      normalizeFacetValue(q.Color),
+		size:       normalizeFacetValue(q.Size),
+		material:   normalizeFacetValue(q.Material),
+	}
+
+	if p.pageSize <= 0 {
+		p.pageSize = defaultPageSize
+	}
+	if p.pageSize > maxPageSize {
+		p.pageSize = maxPageSize
+	}
+	if p.page <= 0 {
+		p.page = 1
+	}
+
+	sortName := strings.ToLower(q.Sort)
+	if sortName == "" {
+		sortName = fallbackSort
+	}
+	spec, ok := sorts[sortName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSort, q.Sort)
+	}
+	p.sort = spec
+
+	switch strings.ToLower(q.Order) {
+	case "", "desc":
+		p.desc = true
+	case "asc":
+		p.desc = false
+	default:
+		return nil, fmt.Errorf("%w: order must be asc or desc", ErrInvalidSort)
+	}
+
+	if q.Cursor != "" {
+		cursor, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != p.sort.name || cursor.Desc != p.desc {
+			return nil, fmt.Errorf("%w: cursor does not match sort order", ErrInvalidCursor)
+		}
+		p.cursor = cursor
+	}
+
+	var err error
+	if p.minPrice, err = parsePrice(q.MinPrice); err != nil {
+		return nil, err
+	}
+	if p.maxPrice, err = parsePrice(q.MaxPrice); err != nil {
+		return nil, err
+	}
+	if p.minPrice != nil && p.maxPrice != nil && p.minPrice.GreaterThan(*p.maxPrice) {
+		return nil, fmt.Errorf("%w: min_price is greater than max_price", ErrInvalidPriceSpan)
+	}
+
+	return p, nil
+}
+
+func parsePrice(s string) (*decimal.Decimal, error) {
+	if s == "" {
+		return nil, nil
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil || d.IsNegative() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPriceSpan, s)
+	}
+	return &d, nil
+}
+
+// nextCursor строит курсор, указывающий на позицию после последнего продукта страницы.
+func (p *productListParams) nextCursor(last *Product) string {
+	return encodeCursor(productCursor{
+		Sort:  p.sort.name,
+		Desc:  p.desc,
+		Value: p.sort.value(last),
+		ID:    last.ID,
+	})
+}
@@ -5,67 +5,52 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// EventID во всех конвертах — ключ идемпотентности: повторная доставка события с тем же ID игнорируется.
 type BaseProductEvent struct {
-	Action string 				 	`json:"action"`
-	UserID  int64            		`json:"user_id"`
-	Product ProductCreatedEvent  	`json:"product"`
+	EventID string              `json:"event_id"`
+	Action  string              `json:"action"`
+	UserID  int64               `json:"user_id"`
+	Product ProductCreatedEvent `json:"product"`
 }
 
 type ProductCreatedEvent struct {
-	Name        	string 			`json:"name"`
-	Description 	string 			`json:"description"`
-	Material    	string 			`gorm:"type:varchar(200)"`
-	IsActive    	bool   			`json:"is_active"`
-	Rating      	decimal.Decimal `gorm:"type:decimal(8,1);not null;default:0"`
-	ReviewCount   	uint      		`gorm:"not null;default:0"`
-	RatingSum     	uint	  		`gorm:"not null;default:0"`
-	QuestionCount	uint 			`gorm:"default:0"`
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	Material      string          `gorm:"type:varchar(200)"`
+	IsActive      bool            `json:"is_active"`
+	Rating        decimal.Decimal `gorm:"type:decimal(8,1);not null;default:0"`
+	ReviewCount   uint            `gorm:"not null;default:0"`
+	RatingSum     uint            `gorm:"not null;default:0"`
+	QuestionCount uint            `gorm:"default:0"`
 
-	CategoryID  	uint   			`json:"category_id"`
-	BrandID     	uint   			`json:"brand_id"`
+	CategoryID uint `json:"category_id"`
+	BrandID    uint `json:"brand_id"`
 
-	ImageKeys  		[]string 		`json:"image_keys"`
-	VideoKeys  		[]string 		`json:"video_keys"`
+	ImageKeys []string `json:"image_keys"`
+	VideoKeys []string `json:"video_keys"`
 
-	Variants 		[]productVariant.ProductVariant `json:"variants"`
+	Variants []productVariant.ProductVariant `json:"variants"`
 }
 
-
 type ProductCreatedEventForMediaAndSearch struct {
-	Action    	string 		`json:"action"`
-	ProductID 	uint   		`json:"product_id"`
+	Action    string `json:"action"`
+	ProductID uint   `json:"product_id"`
 
 	// Полные данные продукта — для Search Service
-	Name        string 		`json:"name"`
-	Description string  	`json:"description"`
-	Material    string 		`gorm:"type:varchar(200)"`
-	Rating      float64 	`gorm:"type:decimal(8,1);not null;default:0"`
-	ReviewCount	uint   		`gorm:"not null;default:0"`
-	IsActive    bool    	`json:"is_active"`
-	CategoryID  uint    	`json:"category_id"`
-	BrandID     uint    	`json:"brand_id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Material    string  `gorm:"type:varchar(200)"`
+	Rating      float64 `gorm:"type:decimal(8,1);not null;default:0"`
+	ReviewCount uint    `gorm:"not null;default:0"`
+	IsActive    bool    `json:"is_active"`
+	CategoryID  uint    `json:"category_id"`
+	BrandID     uint    `json:"brand_id"`
 
 	// Данные для Media Service
-	ImageKeys 	[]string 	`json:"image_keys"`
-	VideoKeys 	[]string 	`json:"video_keys"`
-
-	Variants 	[]*ProductVariantForEvent `json:"variants"`
-}
+	ImageKeys []string `json:"image_keys"`
+	VideoKeys []string `json:"video_keys"`
 
-type ProductVariantForEvent struct {
-    VariantID      uint     `json:"variant_id"`
-    SKU            string   `json:"sku"`
-    Price          float64  `json:"price"`
-    Discount       float64  `json:"discount"`
-    Sizes          string   `json:"sizes"`
-    Colors         string   `json:"colors"`
-    Stock          uint32   `json:"stock"`
-    Barcode        string   `json:"barcode,omitempty"`
-    Dimensions     string   `json:"dimensions,omitempty"`
-    Images      []string `json:"images,omitempty"`
-    MinOrder       uint     `json:"min_order,omitempty"`
-    IsActive       bool     `json:"is_active"`
-    ReservedStock  uint32   `json:"reserved_stock,omitempty"`
+	Variants []*productVariant.ProductVariantForEvent `json:"variants"`
 }
 
 // type ProductCreatedEventForMedia struct {
@@ -75,9 +60,142 @@ type ProductVariantForEvent struct {
 // 	VideoKeys []string 	`json:"video_keys"`
 // }
 
+type BaseProductUpdateEvent struct {
+	EventID   string       `json:"event_id"`
+	Action    string       `json:"action"`
+	UserID    int64        `json:"user_id"`
+	ProductID uint         `json:"product_id"`
+	Product   ProductPatch `json:"product"`
+}
+
+type BaseProductDeleteEvent struct {
+	EventID   string `json:"event_id"`
+	Action    string `json:"action"`
+	UserID    int64  `json:"user_id"`
+	ProductID uint   `json:"product_id"`
+}
+
+// ProductPatch — частичное обновление продукта: nil-поля остаются без изменений.
+type ProductPatch struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Material    *string `json:"material"`
+	IsActive    *bool   `json:"is_active"`
+	CategoryID  *uint   `json:"category_id"`
+	BrandID     *uint   `json:"brand_id"`
+}
+
+// ProductDeletedEventForMediaAndSearch — Search удаляет продукт из индекса, Media — его файлы.
+type ProductDeletedEventForMediaAndSearch struct {
+	Action     string   `json:"action"`
+	ProductID  uint     `json:"product_id"`
+	ImageURLs  []string `json:"image_urls"`
+	VideoURLs  []string `json:"video_urls"`
+	VariantIDs []uint   `json:"variant_ids"`
+}
+
 type MediaUpdateEvent struct {
-	Action    string 	`json:"action"`
-	ProductID uint   	`json:"product_id"`
-    ImageURLs []string 	`json:"image_urls"`
-	VideoURLs []string 	`json:"video_urls"`
+	EventID   string   `json:"event_id"`
+	Action    string   `json:"action"`
+	ProductID uint     `json:"product_id"`
+	ImageURLs []string `json:"image_urls"`
+	VideoURLs []string `json:"video_urls"`
+}
+
+// ProductListQuery — параметры листинга продуктов: пагинация, сортировка и фильтры.
+// Пагинация либо постраничная (page), либо по курсору (cursor) — курсор имеет приоритет.
+type ProductListQuery struct {
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+	Cursor   string `form:"cursor"`
+	Sort     string `form:"sort"`  // price, rating, created_at, review_count
+	Order    string `form:"order"` // asc, desc
+
+	CategoryID uint   `form:"category_id"` // включая все подкатегории
+	BrandID    uint   `form:"brand_id"`
+	IsActive   *bool  `form:"is_active"`
+	MinPrice   string `form:"min_price"`
+	MaxPrice   string `form:"max_price"`
+	InStock    bool   `form:"in_stock"`
+	Color      string `form:"color"`    // одно из значений colors хотя бы у одного варианта
+	Size       string `form:"size"`     // одно из значений sizes хотя бы у одного варианта
+	Material   string `form:"material"` // без учёта регистра
+}
+
+// ProductSearchQuery — полнотекстовый поиск с теми же фильтрами и пагинацией, что у листинга.
+// Сортировка по умолчанию — relevance.
+type ProductSearchQuery struct {
+	Q string `form:"q"`
+	ProductListQuery
+}
+
+// ProductHighlight — фрагменты с найденными словами, обёрнутыми в <mark>.
+type ProductHighlight struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ProductSearchItem — продукт из выдачи поиска с подсветкой и релевантностью.
+type ProductSearchItem struct {
+	Product
+	Relevance float64          `json:"relevance"`
+	Highlight ProductHighlight `json:"highlight"`
+}
+
+// ProductSearchPage — конверт страницы поиска продуктов.
+type ProductSearchPage struct {
+	Items      []ProductSearchItem `json:"items"`
+	Total      int64               `json:"total"`
+	Page       int                 `json:"page,omitempty"`
+	PageSize   int                 `json:"page_size"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+// ProductFacetsQuery — фильтры листинга (с q — поиска), для которых считаются фасеты.
+// Пагинация и сортировка игнорируются.
+type ProductFacetsQuery struct {
+	Q string `form:"q"`
+	ProductListQuery
+	PriceBuckets string `form:"price_buckets"` // возрастающие границы через запятую, например "1000,5000"
+}
+
+// FacetRef — значение фасета-справочника (бренд, категория) с числом продуктов.
+type FacetRef struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// FacetValue — строковое значение фасета (цвет, размер, материал) с числом продуктов.
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// PriceBucket — ценовой диапазон [from, to); у последнего диапазона to нет.
+type PriceBucket struct {
+	From  decimal.Decimal  `json:"from"`
+	To    *decimal.Decimal `json:"to,omitempty"`
+	Count int64            `json:"count"`
+}
+
+// ProductFacets — счётчики для фильтров витрины. Фасет считается без собственного фильтра
+// (выбранный бренд не скрывает остальные бренды), категории — внутри выбранного поддерева.
+type ProductFacets struct {
+	Total      int64         `json:"total"`
+	Brands     []FacetRef    `json:"brands"`
+	Categories []FacetRef    `json:"categories"`
+	Colors     []FacetValue  `json:"colors"`
+	Sizes      []FacetValue  `json:"sizes"`
+	Materials  []FacetValue  `json:"materials"`
+	Prices     []PriceBucket `json:"prices"`
+}
+
+// ProductPage — конверт страницы листинга продуктов.
+type ProductPage struct {
+	Items      []Product `json:"items"`
+	Total      int64     `json:"total"`
+	Page       int       `json:"page,omitempty"`
+	PageSize   int       `json:"page_size"`
+	NextCursor string    `json:"next_cursor,omitempty"`
 }
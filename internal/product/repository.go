@@ -1,90 +1,534 @@
 package product
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
 
+	"github.com/ShopOnGO/product-service/internal/outbox"
+	"github.com/ShopOnGO/product-service/internal/productVariant"
 	"github.com/ShopOnGO/product-service/pkg/db"
+	"github.com/ShopOnGO/product-service/pkg/interfaces"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ProductRepository предоставляет методы для работы с продуктами в базе данных.
+// variants пересчитывает цены вариантов, когда продукт меняет бренд или категорию:
+// от них зависит, какие акции на него действуют.
 type ProductRepository struct {
-	Db *db.Db
+	Db       *db.Db
+	variants interfaces.VariantRepricer
 }
 
-func NewProductRepository(db *db.Db) *ProductRepository {
+func NewProductRepository(db *db.Db, variants interfaces.VariantRepricer) *ProductRepository {
 	return &ProductRepository{
-		Db: db,
+		Db:       db,
+		variants: variants,
 	}
 }
 
-func (r *ProductRepository) GetAll() ([]Product, error) {
-	var products []Product
-	if err := r.Db.
+// categorySubtreeSQL выбирает ID категории и всех её подкатегорий на любой глубине.
+const categorySubtreeSQL = `WITH RECURSIVE subtree AS (
+	SELECT id FROM categories WHERE id = ? AND deleted_at IS NULL
+	UNION ALL
+	SELECT c.id FROM categories c JOIN subtree s ON c.parent_category_id = s.id WHERE c.deleted_at IS NULL
+) SELECT id FROM subtree`
+
+// searchTermSQL превращает запрос пользователя в tsquery по обоим словарям индекса.
+const searchTermSQL = `(SELECT websearch_to_tsquery('russian', @q) || websearch_to_tsquery('english', @q) AS query) q`
+
+// productSearchSQL отбирает продукты, совпавшие по полнотекстовому индексу или похожие по названию
+// (pg_trgm: запрос с опечаткой близок к одному из слов названия), и считает их релевантность.
+const productSearchSQL = `SELECT products.*,
+	CAST(ts_rank_cd(products.search_vector, q.query) + word_similarity(@q, products.name) AS float8) AS search_rank
+FROM products, ` + searchTermSQL + `
+WHERE products.search_vector @@ q.query OR @q <% products.name`
+
+const productHighlightSQL = `SELECT p.id,
+	ts_headline('russian', p.name, q.query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS name,
+	ts_headline('russian', coalesce(p.description, ''), q.query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10') AS description
+FROM products p, ` + searchTermSQL + `
+WHERE p.id IN @ids`
+
+// List возвращает страницу продуктов по фильтрам и общее число подходящих продуктов.
+// Запрашивается на один элемент больше размера страницы — по нему определяется, есть ли следующая.
+// С поисковым запросом выборка идёт из подзапроса поиска, который добавляет products.search_rank.
+func (r *ProductRepository) List(ctx context.Context, p *productListParams) ([]Product, int64, error) {
+	var total int64
+	if err := r.applyListFilters(r.listSource(p), p).
+		WithContext(ctx).
+		Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	dir, op := "ASC", ">"
+	if p.desc {
+		dir, op = "DESC", "<"
+	}
+
+	query := r.applyListFilters(r.listSource(p), p).
+		WithContext(ctx).
 		Preload("Category").
 		Preload("Brand").
-		Preload("Variants").
-		Find(&products).Error; err != nil {
+		Preload("Variants.Attributes").
+		Order(fmt.Sprintf("%s %s, products.id %s", p.sort.expr, dir, dir)).
+		Limit(p.pageSize + 1)
+
+	if p.cursor != nil {
+		query = query.Where(
+			fmt.Sprintf("(%s, products.id) %s (CAST(? AS %s), ?)", p.sort.expr, op, p.sort.cast),
+			p.cursor.Value, p.cursor.ID,
+		)
+	} else {
+		query = query.Offset((p.page - 1) * p.pageSize)
+	}
+
+	var products []Product
+	if err := query.Find(&products).Error; err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+func (r *ProductRepository) listSource(p *productListParams) *gorm.DB {
+	query := r.Db.Model(&Product{})
+	if p.search != "" {
+		query = query.Table("(?) AS products", r.Db.Raw(productSearchSQL, map[string]interface{}{"q": p.search}))
+	}
+	return query
+}
+
+// Highlights возвращает подсветку найденных слов в названии и описании продуктов страницы.
+// ts_headline дорогой, поэтому считается только для уже выбранных продуктов.
+func (r *ProductRepository) Highlights(ctx context.Context, ids []uint, term string) (map[uint]ProductHighlight, error) {
+	var rows []struct {
+		ID uint
+		ProductHighlight
+	}
+	if err := db.RawScan(r.Db.WithContext(ctx), &rows, productHighlightSQL, map[string]interface{}{"q": term, "ids": ids}); err != nil {
 		return nil, err
 	}
-	return products, nil
+
+	highlights := make(map[uint]ProductHighlight, len(rows))
+	for _, row := range rows {
+		highlights[row.ID] = row.ProductHighlight
+	}
+	return highlights, nil
+}
+
+func (r *ProductRepository) applyListFilters(query *gorm.DB, p *productListParams) *gorm.DB {
+	if p.categoryID != 0 {
+		query = query.Where("products.category_id IN (?)", r.Db.Raw(categorySubtreeSQL, p.categoryID))
+	}
+	if p.brandID != 0 {
+		query = query.Where("products.brand_id = ?", p.brandID)
+	}
+	if p.isActive != nil {
+		query = query.Where("products.is_active = ?", *p.isActive)
+	}
+	if p.material != "" {
+		query = query.Where("lower(trim(products.material)) = ?", p.material)
+	}
+
+	// Фильтры по цене, наличию, цвету и размеру относятся к вариантам: продукт подходит,
+	// если хотя бы один его вариант удовлетворяет всем условиям сразу. Цена — итоговая,
+	// со скидкой варианта и акций: по ней покупатель и видит товар.
+	if p.minPrice != nil || p.maxPrice != nil || p.inStock || p.color != "" || p.size != "" {
+		variants := r.Db.Table("product_variants pv").
+			Select("1").
+			Where("pv.product_id = products.id AND pv.deleted_at IS NULL")
+		if p.minPrice != nil {
+			variants = variants.Where("pv.effective_price >= ?", *p.minPrice)
+		}
+		if p.maxPrice != nil {
+			variants = variants.Where("pv.effective_price <= ?", *p.maxPrice)
+		}
+		if p.inStock {
+			variants = variants.Where("pv.is_active = true AND pv.stock > pv.reserved_stock")
+		}
+		if p.color != "" {
+			variants = variants.Where("? = ANY("+variantValuesSQL("pv.colors")+")", p.color)
+		}
+		if p.size != "" {
+			variants = variants.Where("? = ANY("+variantValuesSQL("pv.sizes")+")", p.size)
+		}
+		query = query.Where("EXISTS (?)", variants)
+	}
+
+	return query
+}
+
+// variantValuesSQL разбирает список через запятую (colors, sizes) в массив нормализованных значений.
+func variantValuesSQL(column string) string {
+	return `regexp_split_to_array(lower(trim(` + column + `)), '\s*,\s*')`
+}
+
+// Фасеты считаются по множеству продуктов из тех же фильтров, что и листинг.
+const (
+	brandFacetSQL = `SELECT b.id, b.name, COUNT(*) AS count
+FROM (?) f JOIN brands b ON b.id = f.brand_id AND b.deleted_at IS NULL
+GROUP BY b.id, b.name ORDER BY count DESC, b.name`
+
+	categoryFacetSQL = `SELECT c.id, c.name, COUNT(*) AS count
+FROM (?) f JOIN categories c ON c.id = f.category_id AND c.deleted_at IS NULL
+GROUP BY c.id, c.name ORDER BY count DESC, c.name`
+
+	materialFacetSQL = `SELECT lower(trim(f.material)) AS value, COUNT(*) AS count
+FROM (?) f WHERE trim(coalesce(f.material, '')) <> ''
+GROUP BY 1 ORDER BY count DESC, value`
+
+	priceFacetSQL = `SELECT width_bucket(pv.effective_price, CAST(? AS numeric[])) AS bucket, COUNT(DISTINCT pv.product_id) AS count
+FROM product_variants pv
+WHERE pv.deleted_at IS NULL AND pv.product_id IN (SELECT f.id FROM (?) f) AND (NOT ? OR (pv.is_active AND pv.stock > pv.reserved_stock))
+GROUP BY bucket ORDER BY bucket`
+)
+
+// variantFacetSQL считает продукты по значениям из списка вариантов; с in_stock учитываются
+// только варианты в наличии — так же, как листинг сочетает условия на одном варианте.
+func variantFacetSQL(column string) string {
+	return `SELECT v.value, COUNT(DISTINCT v.product_id) AS count FROM (
+	SELECT pv.product_id, unnest(` + variantValuesSQL("pv."+column) + `) AS value
+	FROM product_variants pv
+	WHERE pv.deleted_at IS NULL AND pv.product_id IN (SELECT f.id FROM (?) f) AND (NOT ? OR (pv.is_active AND pv.stock > pv.reserved_stock))
+) v WHERE v.value <> ''
+GROUP BY v.value ORDER BY count DESC, v.value`
+}
+
+// priceBucketCount — строка ценового фасета: номер диапазона width_bucket (0 — ниже первой границы).
+type priceBucketCount struct {
+	Bucket int
+	Count  int64
+}
+
+// Facets считает число продуктов по брендам, категориям, цветам, размерам, материалам и ценам.
+func (r *ProductRepository) Facets(ctx context.Context, p *productFacetParams) (*ProductFacets, error) {
+	facets := &ProductFacets{}
+	if err := r.applyListFilters(r.listSource(p.filters), p.filters).WithContext(ctx).Count(&facets.Total).Error; err != nil {
+		return nil, err
+	}
+
+	queries := []struct {
+		sql     string
+		filters *productListParams
+		dest    interface{}
+		stock   bool
+	}{
+		{brandFacetSQL, p.without(func(f *productListParams) { f.brandID = 0 }), &facets.Brands, false},
+		{categoryFacetSQL, p.filters, &facets.Categories, false},
+		{materialFacetSQL, p.without(func(f *productListParams) { f.material = "" }), &facets.Materials, false},
+		{variantFacetSQL("colors"), p.without(func(f *productListParams) { f.color = "" }), &facets.Colors, true},
+		{variantFacetSQL("sizes"), p.without(func(f *productListParams) { f.size = "" }), &facets.Sizes, true},
+	}
+	for _, q := range queries {
+		args := []interface{}{r.facetSource(q.filters)}
+		if q.stock {
+			args = append(args, q.filters.inStock)
+		}
+		if err := db.RawScan(r.Db.WithContext(ctx), q.dest, q.sql, args...); err != nil {
+			return nil, err
+		}
+	}
+
+	prices := p.without(func(f *productListParams) { f.minPrice, f.maxPrice = nil, nil })
+	edges := make(pq.StringArray, 0, len(p.priceEdges))
+	for _, edge := range p.priceEdges {
+		edges = append(edges, edge.String())
+	}
+	var buckets []priceBucketCount
+	if err := db.RawScan(r.Db.WithContext(ctx), &buckets, priceFacetSQL, edges, r.facetSource(prices), prices.inStock); err != nil {
+		return nil, err
+	}
+	facets.Prices = priceBuckets(p.priceEdges, buckets)
+	return facets, nil
+}
+
+func (r *ProductRepository) facetSource(p *productListParams) *gorm.DB {
+	return r.applyListFilters(r.listSource(p), p).
+		Select("products.id, products.brand_id, products.category_id, products.material")
+}
+
+// priceBuckets переводит номера width_bucket в диапазоны; пустые диапазоны тоже возвращаются.
+func priceBuckets(edges []decimal.Decimal, counts []priceBucketCount) []PriceBucket {
+	buckets := make([]PriceBucket, len(edges)+1)
+	for i := range buckets {
+		if i > 0 {
+			buckets[i].From = edges[i-1]
+		}
+		if i < len(edges) {
+			to := edges[i]
+			buckets[i].To = &to
+		}
+	}
+	for _, c := range counts {
+		if c.Bucket >= 0 && c.Bucket < len(buckets) {
+			buckets[c.Bucket].Count = c.Count
+		}
+	}
+	return buckets
 }
 
-func (r *ProductRepository) GetByID(id uint) (*Product, error) {
+func (r *ProductRepository) GetByID(ctx context.Context, id uint) (*Product, error) {
 	var product Product
-	if err := r.Db.
+	if err := r.Db.WithContext(ctx).
 		Preload("Category").
 		Preload("Brand").
-		Preload("Variants").
+		Preload("Variants.Attributes").
 		First(&product, id).Error; err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
 
-func (r *ProductRepository) GetProductsByIDs(ids []uint) ([]Product, error) {
+func (r *ProductRepository) GetProductsByIDs(ctx context.Context, ids []uint) ([]Product, error) {
 	var products []Product
-	if err := r.Db.
+	if err := r.Db.WithContext(ctx).
+		Preload("Category").
+		Preload("Brand").
+		Preload("Variants.Attributes").
 		Find(&products, ids).Error; err != nil {
 		return nil, err
 	}
 	return products, nil
 }
 
-func (r *ProductRepository) Create(product *Product) error {
-	if err := r.Db.Create(product).Error; err != nil {
-		return err
+// ActiveAfter возвращает до limit активных продуктов с ID больше afterID по возрастанию ID.
+func (r *ProductRepository) ActiveAfter(ctx context.Context, afterID uint, limit int) ([]Product, error) {
+	var products []Product
+	err := r.Db.WithContext(ctx).
+		Preload("Category").
+		Preload("Brand").
+		Preload("Variants.Attributes").
+		Where("is_active = ? AND id > ?", true, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&products).Error
+	return products, err
+}
+
+// productEvent строит событие outbox по уже записанному продукту (с проставленными ID).
+type productEvent func(*Product) outbox.Event
+
+// Create сохраняет продукт вместе с вариантами, их ценами по действующим акциям
+// и события outbox в одной транзакции.
+func (r *ProductRepository) Create(ctx context.Context, product *Product, events ...productEvent) error {
+	return r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		if err := productVariant.ApplyCampaigns(tx, product.Variants, time.Now()); err != nil {
+			return err
+		}
+		return enqueue(tx, product, events)
+	})
+}
+
+// Update сохраняет поля продукта; категория, бренд и варианты через связи не пишутся.
+// При replaceVariantAttributes атрибуты вариантов заменяются на product.Variants[i].Attributes
+// в той же транзакции — так сохраняются атрибуты, пересобранные для новой категории.
+// Если сменились бренд или категория, цены вариантов пересчитываются там же, и события
+// строятся уже с новыми ценами.
+func (r *ProductRepository) Update(ctx context.Context, product *Product, replaceVariantAttributes bool, events ...productEvent) error {
+	return r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var previous Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "brand_id", "category_id").
+			First(&previous, product.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Save(product).Error; err != nil {
+			return err
+		}
+		if replaceVariantAttributes {
+			for i := range product.Variants {
+				if err := productVariant.SaveAttributes(tx, &product.Variants[i]); err != nil {
+					return err
+				}
+			}
+		}
+		if previous.BrandID != product.BrandID || previous.CategoryID != product.CategoryID {
+			if err := r.repriceProducts(tx, []uint{product.ID}); err != nil {
+				return err
+			}
+			if err := tx.Preload("Attributes").Where("product_id = ?", product.ID).Order("id").Find(&product.Variants).Error; err != nil {
+				return err
+			}
+		}
+		return enqueue(tx, product, events)
+	})
+}
+
+// Delete мягко удаляет продукт и все его варианты в одной транзакции.
+func (r *ProductRepository) Delete(ctx context.Context, product *Product, events ...productEvent) error {
+	return r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", product.ID).Delete(&productVariant.ProductVariant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&Product{}, product.ID).Error; err != nil {
+			return err
+		}
+		return enqueue(tx, product, events)
+	})
+}
+
+func enqueue(tx *gorm.DB, product *Product, events []productEvent) error {
+	built := make([]outbox.Event, 0, len(events))
+	for _, event := range events {
+		built = append(built, event(product))
 	}
-	return nil
+	return outbox.Enqueue(tx, built...)
+}
+
+func (r *ProductRepository) IsProductOwnedByUser(ctx context.Context, productID, userID uint) (bool, error) {
+	var prod Product
+	err := r.Db.WithContext(ctx).
+		Select("id").
+		Where("id = ? AND seller_id = ?", productID, userID).
+		First(&prod).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
 }
 
-func (r *ProductRepository) Update(product *Product) error {
-	if err := r.Db.Save(product).Error; err != nil {
+// Методы ниже реализуют interfaces.ProductDetacher: их вызывают репозитории брендов и категорий
+// внутри своей транзакции удаления. События продуктов пишутся в outbox той же транзакцией.
+
+func (r *ProductRepository) CountByRef(tx *gorm.DB, ref interfaces.ProductRef, id uint) (int64, error) {
+	var count int64
+	err := tx.Model(&Product{}).Where(refCondition(ref), id).Count(&count).Error
+	return count, err
+}
+
+// Reassign переносит продукты на targetID, пересчитывает цены их вариантов по акциям новой
+// ссылки и рассылает product-updated. При переносе в другую категорию атрибуты вариантов
+// перепроверяются по её определениям, как при смене категории продукта; если хоть один
+// вариант не проходит, возвращается ErrReassignAttributes и транзакция вызывающего откатывается.
+func (r *ProductRepository) Reassign(tx *gorm.DB, ref interfaces.ProductRef, id, targetID uint) (int64, error) {
+	ids, err := productIDsByRef(tx, ref, id)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	if ref == interfaces.RefCategory {
+		if err := reapplyAttributes(tx, ids, targetID); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Model(&Product{}).Where("id IN ?", ids).Update(string(ref), targetID).Error; err != nil {
+		return 0, err
+	}
+	if err := r.repriceProducts(tx, ids); err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), enqueueByIDs(tx, ids, productUpdatedEvent)
+}
+
+// Deactivate снимает продукты с продажи; ссылка на бренд или категорию остаётся.
+func (r *ProductRepository) Deactivate(tx *gorm.DB, ref interfaces.ProductRef, id uint) (int64, error) {
+	ids, err := productIDsByRef(tx, ref, id)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	if err := tx.Model(&Product{}).Where("id IN ?", ids).Update("is_active", false).Error; err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), enqueueByIDs(tx, ids, productUpdatedEvent)
+}
+
+// DeleteByRef мягко удаляет продукты вместе с вариантами, как Delete для одного продукта.
+func (r *ProductRepository) DeleteByRef(tx *gorm.DB, ref interfaces.ProductRef, id uint) (int64, error) {
+	ids, err := productIDsByRef(tx, ref, id)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	// События собираются до удаления: после него продукты и варианты уже не прочитать.
+	products, err := productsWithVariants(tx, ids)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Where("product_id IN ?", ids).Delete(&productVariant.ProductVariant{}).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Delete(&Product{}, ids).Error; err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), enqueueAll(tx, products, productDeletedEvent)
+}
+
+// repriceProducts пересчитывает цены вариантов продуктов ids после смены бренда или категории.
+func (r *ProductRepository) repriceProducts(tx *gorm.DB, ids []uint) error {
+	var variantIDs []uint
+	if err := tx.Model(&productVariant.ProductVariant{}).
+		Where("product_id IN ?", ids).
+		Order("id").
+		Pluck("id", &variantIDs).Error; err != nil {
 		return err
 	}
-	return nil
+	_, err := r.variants.Reprice(tx, variantIDs, time.Now())
+	return err
 }
 
-func (r *ProductRepository) Delete(id uint) error {
-	if err := r.Db.Delete(&Product{}, id).Error; err != nil {
+// reapplyAttributes перепроверяет атрибуты вариантов продуктов ids по категории categoryID и сохраняет их.
+func reapplyAttributes(tx *gorm.DB, ids []uint, categoryID uint) error {
+	products, err := productsWithVariants(tx, ids)
+	if err != nil {
 		return err
 	}
+	for i := range products {
+		for j := range products[i].Variants {
+			variant := &products[i].Variants[j]
+			if err := productVariant.ReapplyAttributes(tx, categoryID, variant); err != nil {
+				return fmt.Errorf("%w: product %d, variant %d: %w", interfaces.ErrReassignAttributes, products[i].ID, variant.ID, err)
+			}
+			if err := productVariant.SaveAttributes(tx, variant); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
-func (r *ProductRepository) IsProductOwnedByUser(productID, userID uint) (bool, error) {
-    var prod Product
-    err := r.Db.
-        Select("id").
-        Where("id = ? AND seller_id = ?", productID, userID).
-        First(&prod).Error
+func refCondition(ref interfaces.ProductRef) string {
+	if ref == interfaces.RefBrand {
+		return "brand_id = ?"
+	}
+	return "category_id = ?"
+}
+
+// productIDsByRef блокирует продукты до конца транзакции, чтобы их не изменили параллельно.
+func productIDsByRef(tx *gorm.DB, ref interfaces.ProductRef, id uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Model(&Product{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(refCondition(ref), id).Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+func enqueueByIDs(tx *gorm.DB, ids []uint, event productEvent) error {
+	products, err := productsWithVariants(tx, ids)
+	if err != nil {
+		return err
+	}
+	return enqueueAll(tx, products, event)
+}
 
-    if err != nil {
-        if errors.Is(err, gorm.ErrRecordNotFound) {
-            return false, nil
-        }
-        return false, err
-    }
+func productsWithVariants(tx *gorm.DB, ids []uint) ([]Product, error) {
+	var products []Product
+	err := tx.Preload("Variants.Attributes").Find(&products, ids).Error
+	return products, err
+}
 
-    return true, nil
-}
\ No newline at end of file
+func enqueueAll(tx *gorm.DB, products []Product, event productEvent) error {
+	events := make([]outbox.Event, 0, len(products))
+	for i := range products {
+		events = append(events, event(&products[i]))
+	}
+	return outbox.Enqueue(tx, events...)
+}
@@ -1,60 +1,236 @@
 package product
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/ShopOnGO/product-service/internal/attribute"
+	"github.com/ShopOnGO/product-service/internal/auth"
+	"github.com/ShopOnGO/product-service/internal/productVariant"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrInvalidProduct  = errors.New("invalid product")
+	ErrProductNotFound = errors.New("product not found")
+)
+
+const (
+	maxProductNameLength = 255
+
+	defaultStreamBatchSize = 100
+	maxStreamBatchSize     = 1000
+)
+
 type ProductService struct {
-	repo *ProductRepository
+	repo       *ProductRepository
+	attributes *attribute.AttributeService
 }
 
-func NewProductService(repository *ProductRepository) *ProductService {
+func NewProductService(repository *ProductRepository, attributes *attribute.AttributeService) *ProductService {
 	return &ProductService{
-		repo: repository,
+		repo:       repository,
+		attributes: attributes,
 	}
 }
 
-func (s *ProductService) GetAllProducts() ([]Product, error) {
-	products, err := s.repo.GetAll()
+// ListProducts возвращает страницу продуктов с учётом фильтров, сортировки и пагинации.
+func (s *ProductService) ListProducts(ctx context.Context, q ProductListQuery) (*ProductPage, error) {
+	params, err := newProductListParams(q)
 	if err != nil {
 		return nil, err
 	}
-	return products, nil
+
+	products, total, err := s.repo.List(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ProductPage{
+		Items:    products,
+		Total:    total,
+		PageSize: params.pageSize,
+	}
+	if params.cursor == nil {
+		page.Page = params.page
+	}
+	if len(products) > params.pageSize {
+		page.Items = products[:params.pageSize]
+		page.NextCursor = params.nextCursor(&page.Items[len(page.Items)-1])
+	}
+	return page, nil
 }
 
-func (s *ProductService) GetProductByID(id uint) (*Product, error) {
-	product, err := s.repo.GetByID(id)
+// SearchProducts ищет продукты по тексту с фильтрами и пагинацией листинга и подсвечивает совпадения.
+func (s *ProductService) SearchProducts(ctx context.Context, q ProductSearchQuery) (*ProductSearchPage, error) {
+	params, err := newProductSearchParams(q)
+	if err != nil {
+		return nil, err
+	}
+
+	products, total, err := s.repo.List(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ProductSearchPage{
+		Total:    total,
+		PageSize: params.pageSize,
+	}
+	if params.cursor == nil {
+		page.Page = params.page
+	}
+	if len(products) > params.pageSize {
+		products = products[:params.pageSize]
+		page.NextCursor = params.nextCursor(&products[len(products)-1])
+	}
+
+	ids := make([]uint, 0, len(products))
+	for i := range products {
+		ids = append(ids, products[i].ID)
+	}
+	highlights := map[uint]ProductHighlight{}
+	if len(ids) > 0 {
+		if highlights, err = s.repo.Highlights(ctx, ids, params.search); err != nil {
+			return nil, err
+		}
+	}
+
+	page.Items = make([]ProductSearchItem, 0, len(products))
+	for i := range products {
+		page.Items = append(page.Items, ProductSearchItem{
+			Product:   products[i],
+			Relevance: products[i].SearchRank,
+			Highlight: highlights[products[i].ID],
+		})
+	}
+	return page, nil
+}
+
+// GetFacets считает фасеты витрины для фильтров листинга, а при заданном q — для выдачи поиска.
+func (s *ProductService) GetFacets(ctx context.Context, q ProductFacetsQuery) (*ProductFacets, error) {
+	params, err := newProductFacetParams(q)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Facets(ctx, params)
+}
+
+func (s *ProductService) GetProductByID(ctx context.Context, id uint) (*Product, error) {
+	product, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("product not found")
+			return nil, ErrProductNotFound
 		}
 		return nil, err
 	}
 	return product, nil
 }
 
-func (s *ProductService) GetProductsByIDs(ids []uint) ([]Product, error) {
-	products, err := s.repo.GetProductsByIDs(ids)
+// GetProductsByIDs возвращает продукты по ID; если хотя бы одного нет, возвращает ErrProductNotFound со списком отсутствующих.
+func (s *ProductService) GetProductsByIDs(ctx context.Context, ids []uint) ([]Product, error) {
+	products, err := s.repo.GetProductsByIDs(ctx, ids)
 	if err != nil {
 		return nil, err
 	}
+	found := make(map[uint]bool, len(products))
+	for i := range products {
+		found[products[i].ID] = true
+	}
+	var missing []uint
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %v", ErrProductNotFound, missing)
+	}
 	return products, nil
 }
 
-func (s *ProductService) CreateProduct(product *Product) (*Product, error) {
-	if err := s.repo.Create(product); err != nil {
+// StreamActiveProducts передаёт send все активные продукты по возрастанию ID, читая их пачками по batchSize.
+// Остановка send или отмена ctx прерывает обход.
+func (s *ProductService) StreamActiveProducts(ctx context.Context, batchSize int, send func(*Product) error) error {
+	if batchSize <= 0 {
+		batchSize = defaultStreamBatchSize
+	}
+	if batchSize > maxStreamBatchSize {
+		batchSize = maxStreamBatchSize
+	}
+
+	afterID := uint(0)
+	for {
+		products, err := s.repo.ActiveAfter(ctx, afterID, batchSize)
+		if err != nil {
+			return err
+		}
+		for i := range products {
+			if err := send(&products[i]); err != nil {
+				return err
+			}
+		}
+		if len(products) < batchSize {
+			return nil
+		}
+		afterID = products[len(products)-1].ID
+	}
+}
+
+// validateProduct проверяет поля продукта одинаково для REST и Kafka.
+func validateProduct(product *Product) error {
+	name := strings.TrimSpace(product.Name)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidProduct)
+	}
+	if utf8.RuneCountInString(name) > maxProductNameLength {
+		return fmt.Errorf("%w: name must be at most %d characters", ErrInvalidProduct, maxProductNameLength)
+	}
+	if product.CategoryID == 0 {
+		return fmt.Errorf("%w: category_id is required", ErrInvalidProduct)
+	}
+	if product.BrandID == 0 {
+		return fmt.Errorf("%w: brand_id is required", ErrInvalidProduct)
+	}
+	return nil
+}
+
+// CreateProduct создаёт продукт вместе с переданными вариантами; product-created
+// попадает в outbox в той же транзакции.
+// Владельцем становится создавший продавец; администратор может указать seller_id сам.
+func (s *ProductService) CreateProduct(ctx context.Context, product *Product) (*Product, error) {
+	actor, err := auth.RequireRole(ctx, auth.RoleSeller)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.IsAdmin() || product.SellerID == 0 {
+		product.SellerID = actor.UserID
+	}
+	if err := validateProduct(product); err != nil {
+		return nil, err
+	}
+	if err := s.applyVariantAttributes(ctx, product); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, product, productCreatedEvent); err != nil {
 		return nil, err
 	}
 	return product, nil
 }
 
-func (s *ProductService) UpdateProduct(id uint, updated *Product) (*Product, error) {
-	product, err := s.repo.GetByID(id)
+func (s *ProductService) UpdateProduct(ctx context.Context, id uint, updated *Product) (*Product, error) {
+	product, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if _, err := auth.RequireOwner(ctx, product.SellerID); err != nil {
+		return nil, err
+	}
+
+	previousCategoryID := product.CategoryID
 
 	// Обновляем только нужные поля
 	product.Name = updated.Name
@@ -66,15 +242,70 @@ func (s *ProductService) UpdateProduct(id uint, updated *Product) (*Product, err
 	product.ImageURLs = updated.ImageURLs
 	product.VideoURLs = updated.VideoURLs
 
-	if err := s.repo.Update(product); err != nil {
+	if err := validateProduct(product); err != nil {
+		return nil, err
+	}
+	recategorized := product.CategoryID != previousCategoryID
+	if recategorized {
+		if err := s.applyVariantAttributes(ctx, product); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.Update(ctx, product, recategorized, productUpdatedEvent); err != nil {
 		return nil, err
 	}
 
 	return product, nil
 }
 
-func (s *ProductService) UpdateProductMedia(productID uint, images []string, video []string) error {
-	product, err := s.repo.GetByID(productID)
+// PatchProduct обновляет только переданные поля продукта.
+func (s *ProductService) PatchProduct(ctx context.Context, id uint, patch ProductPatch) (*Product, error) {
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := auth.RequireOwner(ctx, product.SellerID); err != nil {
+		return nil, err
+	}
+
+	previousCategoryID := product.CategoryID
+
+	if patch.Name != nil {
+		product.Name = *patch.Name
+	}
+	if patch.Description != nil {
+		product.Description = *patch.Description
+	}
+	if patch.Material != nil {
+		product.Material = *patch.Material
+	}
+	if patch.IsActive != nil {
+		product.IsActive = *patch.IsActive
+	}
+	if patch.CategoryID != nil {
+		product.CategoryID = *patch.CategoryID
+	}
+	if patch.BrandID != nil {
+		product.BrandID = *patch.BrandID
+	}
+
+	if err := validateProduct(product); err != nil {
+		return nil, err
+	}
+	recategorized := product.CategoryID != previousCategoryID
+	if recategorized {
+		if err := s.applyVariantAttributes(ctx, product); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.Update(ctx, product, recategorized, productUpdatedEvent); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (s *ProductService) UpdateProductMedia(ctx context.Context, productID uint, images []string, video []string) error {
+	product, err := s.repo.GetByID(ctx, productID)
 	if err != nil {
 		return err
 	}
@@ -82,14 +313,38 @@ func (s *ProductService) UpdateProductMedia(productID uint, images []string, vid
 	product.ImageURLs = images
 	product.VideoURLs = video
 
-	return s.repo.Update(product)
+	// Без события: медиа пришли от Media Service, возвращать их обратно незачем.
+	return s.repo.Update(ctx, product, false)
 }
 
+// applyVariantAttributes проверяет атрибуты вариантов по определениям категории продукта.
+// Заданные значения проверяются строго, поэтому смена категории, несовместимой с атрибутами
+// вариантов, отклоняется; вариант без attributes получает их из строк sizes, colors и dimensions.
+func (s *ProductService) applyVariantAttributes(ctx context.Context, product *Product) error {
+	for i := range product.Variants {
+		variant := &product.Variants[i]
+		var inputs []attribute.ValueInput
+		for _, v := range variant.Attributes {
+			inputs = append(inputs, v.Input())
+		}
+		if err := productVariant.ApplyAttributes(ctx, s.attributes, product.CategoryID, variant, inputs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-func (s *ProductService) DeleteProduct(id uint) error {
-	_, err := s.repo.GetByID(id)
+// DeleteProduct мягко удаляет продукт вместе с его вариантами и возвращает удалённый продукт.
+func (s *ProductService) DeleteProduct(ctx context.Context, id uint) (*Product, error) {
+	product, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if _, err := auth.RequireOwner(ctx, product.SellerID); err != nil {
+		return nil, err
 	}
-	return s.repo.Delete(id)
+	if err := s.repo.Delete(ctx, product, productDeletedEvent); err != nil {
+		return nil, err
+	}
+	return product, nil
 }
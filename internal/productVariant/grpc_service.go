@@ -4,62 +4,246 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
-	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
 	pb "github.com/ShopOnGO/product-proto/pkg/product"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"gorm.io/gorm"
 )
 
 type GrpcProductVariantService struct {
-    pb.UnimplementedProductVariantServiceServer
-    productVariantSvc *ProductVariantService
+	pb.UnimplementedProductVariantServiceServer
+	productVariantSvc *ProductVariantService
+	reservationSvc    *ReservationService
 }
 
-func NewGrpcProductVariantService(svc *ProductVariantService) *GrpcProductVariantService {
-    return &GrpcProductVariantService{productVariantSvc: svc}
+func NewGrpcProductVariantService(svc *ProductVariantService, reservationSvc *ReservationService) *GrpcProductVariantService {
+	return &GrpcProductVariantService{productVariantSvc: svc, reservationSvc: reservationSvc}
 }
 
 func (g *GrpcProductVariantService) CheckProductVariantExists(ctx context.Context, req *pb.CheckProductVariantRequest) (*pb.CheckProductVariantResponse, error) {
-    variant, err := g.productVariantSvc.GetProductVariantByID(uint(req.ProductVariantId))
-    if err != nil {
-        if errors.Is(err, gorm.ErrRecordNotFound) {
-            return &pb.CheckProductVariantResponse{Exists: false, IsActive: false}, nil
-        }
-        logger.Errorf("CheckProductVariantExists error: %v", err)
-        return nil, fmt.Errorf("internal error checking variant: %w", err)
-    }
-
-    return &pb.CheckProductVariantResponse{
-        Exists:   true,
-        IsActive: variant.IsActive,
-    }, nil
+	if req.ProductVariantId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "product_variant_id is required")
+	}
+	variant, err := g.productVariantSvc.GetProductVariantByID(ctx, uint(req.ProductVariantId))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &pb.CheckProductVariantResponse{Exists: false, IsActive: false}, nil
+		}
+		return nil, err
+	}
+
+	return &pb.CheckProductVariantResponse{
+		Exists:   true,
+		IsActive: variant.IsActive,
+	}, nil
 }
 
 func (g *GrpcProductVariantService) GetProductVariants(ctx context.Context, req *pb.GetProductVariantsRequest) (*pb.GetProductVariantsResponse, error) {
-    ids := make([]uint, len(req.ProductVariantIds))
-    for i, id := range req.ProductVariantIds {
-        ids[i] = uint(id)
-    }
-
-    variants, err := g.productVariantSvc.GetVariantsByIDs(ids)
-    if err != nil {
-        logger.Errorf("GetProductVariants error: %v", err)
-        return nil, fmt.Errorf("internal error fetching variants: %w", err)
-    }
-
-    resp := &pb.GetProductVariantsResponse{}
-    for _, v := range variants {
-        resp.ProductVariants = append(resp.ProductVariants, &pb.ProductVariant{
-            Id:            uint64(v.ID),
-            ProductId:     uint64(v.ProductID),
-            Sku:           v.SKU,
-            Price:         v.Price.String(),
-            Discount:      v.Discount.String(),
-            IsActive:      v.IsActive,
-            Stock:         uint32(v.Stock),
-            Images:        v.ImageURLs,
-        })
-    }
-
-    return resp, nil
+	ids := make([]uint, len(req.ProductVariantIds))
+	for i, id := range req.ProductVariantIds {
+		ids[i] = uint(id)
+	}
+
+	variants, err := g.productVariantSvc.GetVariantsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.GetProductVariantsResponse{}
+	for i := range variants {
+		resp.ProductVariants = append(resp.ProductVariants, VariantToProto(&variants[i]))
+	}
+
+	return resp, nil
+}
+
+func (g *GrpcProductVariantService) ListProductVariants(ctx context.Context, req *pb.ListProductVariantsRequest) (*pb.ListProductVariantsResponse, error) {
+	page, err := g.productVariantSvc.ListVariants(ctx, VariantListQuery{
+		Page:        int(req.Page),
+		PageSize:    int(req.PageSize),
+		Sort:        req.Sort,
+		Order:       req.Order,
+		ProductID:   uint(req.ProductId),
+		MinPrice:    req.MinPrice,
+		MaxPrice:    req.MaxPrice,
+		Size:        req.Size,
+		Color:       req.Color,
+		InStock:     req.InStock,
+		IsActive:    req.IsActive,
+		HasDiscount: req.HasDiscount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListProductVariantsResponse{
+		Total:    page.Total,
+		Page:     uint32(page.Page),
+		PageSize: uint32(page.PageSize),
+	}
+	for i := range page.Items {
+		resp.ProductVariants = append(resp.ProductVariants, VariantToProto(&page.Items[i]))
+	}
+	return resp, nil
+}
+
+// VariantToProto переводит вариант в proto-сообщение вместе с атрибутами.
+func VariantToProto(v *ProductVariant) *pb.ProductVariant {
+	msg := &pb.ProductVariant{
+		Id:               uint64(v.ID),
+		ProductId:        uint64(v.ProductID),
+		Sku:              v.SKU,
+		Price:            v.Price.String(),
+		Discount:         v.Discount.String(),
+		CampaignDiscount: v.CampaignDiscount.String(),
+		EffectivePrice:   v.EffectivePrice.String(),
+		IsActive:         v.IsActive,
+		Stock:            uint32(v.Stock),
+		Images:           v.ImageURLs,
+		Sizes:            v.Sizes,
+		Colors:           v.Colors,
+		ReservedStock:    v.ReservedStock,
+		AvailableStock:   v.AvailableStock(),
+		Barcode:          v.Barcode,
+		MinOrder:         uint32(v.MinOrder),
+		Dimensions:       v.Dimensions,
+	}
+	for _, a := range v.Attributes {
+		attr := &pb.VariantAttribute{
+			Code:  a.Code,
+			Value: a.Value,
+			Hex:   a.Hex,
+			Unit:  a.Unit,
+		}
+		if a.Number != nil {
+			attr.Number = a.Number.String()
+		}
+		msg.Attributes = append(msg.Attributes, attr)
+	}
+	return msg
+}
+
+func (g *GrpcProductVariantService) ReserveStockBatch(ctx context.Context, req *pb.ReserveStockBatchRequest) (*pb.ReserveStockBatchResponse, error) {
+	items := make([]ReservationItem, len(req.Lines))
+	for i, line := range req.Lines {
+		items[i] = ReservationItem{ProductVariantID: uint(line.ProductVariantId), Quantity: line.Quantity}
+	}
+
+	reservation, err := g.reservationSvc.Reserve(ctx, req.OrderId, items, time.Duration(req.TtlSeconds)*time.Second)
+	if err != nil {
+		return nil, stockStatus(err)
+	}
+
+	resp := &pb.ReserveStockBatchResponse{
+		ReservationId: reservation.ID,
+		ExpiresAt:     timestamppb.New(reservation.ExpiresAt),
+	}
+	for _, item := range reservation.Items {
+		resp.Lines = append(resp.Lines, &pb.ReservationLine{
+			ProductVariantId: uint32(item.ProductVariantID),
+			Quantity:         item.Quantity,
+		})
+	}
+	return resp, nil
+}
+
+func (g *GrpcProductVariantService) ReserveStock(ctx context.Context, req *pb.ReserveStockRequest) (*pb.ReserveStockResponse, error) {
+	reservation, err := g.reservationSvc.Reserve(
+		ctx,
+		req.OrderId,
+		[]ReservationItem{{ProductVariantID: uint(req.ProductVariantId), Quantity: req.Quantity}},
+		time.Duration(req.TtlSeconds)*time.Second,
+	)
+	if err != nil {
+		// Для одного варианта отсутствие — это NOT_FOUND, а не недостача
+		var shortage *InsufficientStockError
+		if errors.As(err, &shortage) && len(shortage.Shortfalls) == 1 && shortage.Shortfalls[0].Reason == ShortfallNotFound {
+			return nil, status.Errorf(codes.NotFound, "product variant %d not found", req.ProductVariantId)
+		}
+		return nil, stockStatus(err)
+	}
+	return &pb.ReserveStockResponse{
+		ReservationId: reservation.ID,
+		ExpiresAt:     timestamppb.New(reservation.ExpiresAt),
+	}, nil
+}
+
+func (g *GrpcProductVariantService) ReleaseStock(ctx context.Context, req *pb.ReleaseStockRequest) (*pb.ReservationStatusResponse, error) {
+	if req.ReservationId == "" {
+		return nil, status.Error(codes.InvalidArgument, "reservation_id is required")
+	}
+	reservation, err := g.reservationSvc.Cancel(ctx, req.ReservationId)
+	if err != nil {
+		return nil, stockStatus(err)
+	}
+	return reservationStatusResponse(reservation), nil
+}
+
+func (g *GrpcProductVariantService) CommitStock(ctx context.Context, req *pb.CommitStockRequest) (*pb.ReservationStatusResponse, error) {
+	if req.ReservationId == "" {
+		return nil, status.Error(codes.InvalidArgument, "reservation_id is required")
+	}
+	reservation, err := g.reservationSvc.Commit(ctx, req.ReservationId)
+	if err != nil {
+		return nil, stockStatus(err)
+	}
+	return reservationStatusResponse(reservation), nil
+}
+
+func (g *GrpcProductVariantService) GetAvailableStock(ctx context.Context, req *pb.GetAvailableStockRequest) (*pb.GetAvailableStockResponse, error) {
+	if req.ProductVariantId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "product_variant_id is required")
+	}
+	available, err := g.productVariantSvc.GetAvailableStock(ctx, uint(req.ProductVariantId))
+	if err != nil {
+		return nil, stockStatus(err)
+	}
+	return &pb.GetAvailableStockResponse{
+		ProductVariantId: req.ProductVariantId,
+		AvailableStock:   available,
+	}, nil
+}
+
+func reservationStatusResponse(reservation *Reservation) *pb.ReservationStatusResponse {
+	resp := &pb.ReservationStatusResponse{
+		ReservationId: reservation.ID,
+		Status:        string(reservation.Status),
+	}
+	for _, item := range reservation.Items {
+		resp.Lines = append(resp.Lines, &pb.ReservationLine{
+			ProductVariantId: uint32(item.ProductVariantID),
+			Quantity:         item.Quantity,
+		})
+	}
+	return resp
+}
+
+// stockStatus добавляет к недостаче детали по строкам; остальные ошибки переводит в статусы интерцептор.
+func stockStatus(err error) error {
+	var shortage *InsufficientStockError
+	if errors.As(err, &shortage) {
+		return shortfallStatus(shortage).Err()
+	}
+	return err
+}
+
+// shortfallStatus описывает недостачу по каждой строке корзины в деталях FAILED_PRECONDITION.
+func shortfallStatus(shortage *InsufficientStockError) *status.Status {
+	st := status.New(codes.FailedPrecondition, shortage.Error())
+	failure := &errdetails.PreconditionFailure{}
+	for _, s := range shortage.Shortfalls {
+		failure.Violations = append(failure.Violations, &errdetails.PreconditionFailure_Violation{
+			Type:        s.Reason,
+			Subject:     fmt.Sprintf("product_variant:%d", s.ProductVariantID),
+			Description: fmt.Sprintf("requested %d, available %d", s.Requested, s.Available),
+		})
+	}
+	if detailed, err := st.WithDetails(failure); err == nil {
+		return detailed
+	}
+	return st
 }
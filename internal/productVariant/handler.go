@@ -1,48 +1,99 @@
 package productVariant
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/product-service/internal/auth"
 	"github.com/gin-gonic/gin"
 )
 
 // Новая структура для зависимостей
 type ProductVariantHandlerDeps struct {
 	ProductVariantSvc *ProductVariantService
+	ReservationSvc    *ReservationService
 	Kafka             *kafkaService.KafkaService
+	Auth              gin.HandlerFunc // проверка JWT для изменяющих запросов
 }
 
 type ProductVariantHandler struct {
 	productVariantSvc *ProductVariantService
+	reservationSvc    *ReservationService
 	Kafka             *kafkaService.KafkaService // Добавлено
 }
 
 func NewProductVariantHandler(router *gin.Engine, deps ProductVariantHandlerDeps) *ProductVariantHandler {
 	handler := &ProductVariantHandler{
 		productVariantSvc: deps.ProductVariantSvc,
+		reservationSvc:    deps.ReservationSvc,
 		Kafka:             deps.Kafka,
 	}
 
 	variantGroup := router.Group("/product-service/product-variants")
 	{
+		variantGroup.GET("/", handler.GetProductVariants)
 		variantGroup.GET("/:id", handler.GetProductVariantByID)
 		variantGroup.GET("/by-sku", handler.GetProductVariantBySKU)
-		variantGroup.POST("/", handler.CreateProductVariant)
-		variantGroup.PUT("/:id", handler.UpdateProductVariant)
-		variantGroup.DELETE("/:id", handler.DeleteProductVariant)
-
-		variantGroup.POST("/:id/reserve", handler.ReserveStock)
-		variantGroup.POST("/:id/release", handler.ReleaseStock)
-		variantGroup.PUT("/:id/stock", handler.UpdateStock)
+		variantGroup.POST("/", deps.Auth, handler.CreateProductVariant)
+		variantGroup.PUT("/:id", deps.Auth, handler.UpdateProductVariant)
+		variantGroup.DELETE("/:id", deps.Auth, handler.DeleteProductVariant)
+
+		variantGroup.POST("/reserve", deps.Auth, auth.RequireRoles(auth.RoleService), handler.ReserveStockBatch)
+		variantGroup.POST("/:id/reserve", deps.Auth, auth.RequireRoles(auth.RoleService), handler.ReserveStock)
+		variantGroup.POST("/:id/release", deps.Auth, auth.RequireRoles(auth.RoleService), handler.ReleaseStock)
+		variantGroup.PUT("/:id/stock", deps.Auth, handler.UpdateStock)
 		variantGroup.GET("/:id/available", handler.GetAvailableStock)
 	}
 
 	return handler
 }
 
+// GetProductVariants ищет варианты по фильтрам
+// @Summary Поиск вариантов продуктов
+// @Description Возвращает страницу вариантов с фильтрами, сортировкой и постраничной пагинацией
+// @Tags Варианты Продуктов
+// @Accept json
+// @Produce json
+// @Param page query int false "Номер страницы (с 1)"
+// @Param page_size query int false "Размер страницы (по умолчанию 20, максимум 100)"
+// @Param sort query string false "Поле сортировки: created_at, price, effective_price, discount, stock"
+// @Param order query string false "Направление сортировки: asc, desc"
+// @Param product_id query int false "ID продукта"
+// @Param min_price query string false "Минимальная цена"
+// @Param max_price query string false "Максимальная цена"
+// @Param size query string false "Размер"
+// @Param color query string false "Цвет"
+// @Param in_stock query bool false "Только варианты со свободным остатком"
+// @Param is_active query bool false "Только активные/неактивные варианты"
+// @Param has_discount query bool false "Только варианты со скидкой, в том числе по акции"
+// @Success 200 {object} VariantPage
+// @Failure 400 {object} map[string]string "Неверные параметры запроса"
+// @Failure 500 {object} map[string]string "Ошибка получения вариантов"
+// @Router /product-variants [get]
+func (h *ProductVariantHandler) GetProductVariants(c *gin.Context) {
+	var query VariantListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	page, err := h.productVariantSvc.ListVariants(c.Request.Context(), query)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSort) || errors.Is(err, ErrInvalidPriceSpan) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Errorf("Error listing product variants: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch product variants"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
 // CreateProductVariant создает новый вариант продукта.
 // @Summary Создание нового варианта продукта
 // @Description Создает новый вариант продукта с указанными данными.
@@ -51,8 +102,11 @@ func NewProductVariantHandler(router *gin.Engine, deps ProductVariantHandlerDeps
 // @Produce json
 // @Param variant body CreateProductVariantPayload true "Данные для создания варианта продукта"
 // @Success 201 {object} ProductVariant
-// @Failure 400 {object} map[string]string "Неверное тело запроса"
+// @Failure 400 {object} map[string]string "Неверное тело запроса или атрибуты не прошли проверку"
 // @Failure 500 {object} map[string]string "Ошибка при создании варианта продукта"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Продукт принадлежит другому продавцу"
+// @Security ApiKeyAuth
 // @Router /product-variants [post]
 func (h *ProductVariantHandler) CreateProductVariant(c *gin.Context) {
 	var payload CreateProductVariantPayload
@@ -62,25 +116,24 @@ func (h *ProductVariantHandler) CreateProductVariant(c *gin.Context) {
 	}
 
 	variant := &ProductVariant{
-		ProductID:     payload.ProductID,
-		SKU:           payload.SKU,
-		Price:         payload.Price,
-		Discount:      payload.Discount,
-		ReservedStock: payload.ReservedStock,
-		Sizes:         payload.Sizes,
-		Colors:        payload.Colors,
-		Stock:         payload.Stock,
-		Barcode:       payload.Barcode,
-		IsActive:      payload.IsActive,
-		ImageURLs:     payload.Images,
-		MinOrder:      payload.MinOrder,
-		Dimensions:    payload.Dimensions,
-	}
-
-	created, err := h.productVariantSvc.CreateProductVariant(variant)
+		ProductID:  payload.ProductID,
+		SKU:        payload.SKU,
+		Price:      payload.Price,
+		Discount:   payload.Discount,
+		Sizes:      payload.Sizes,
+		Colors:     payload.Colors,
+		Stock:      payload.Stock,
+		Barcode:    payload.Barcode,
+		IsActive:   payload.IsActive,
+		ImageURLs:  payload.Images,
+		MinOrder:   payload.MinOrder,
+		Dimensions: payload.Dimensions,
+	}
+
+	created, err := h.productVariantSvc.CreateProductVariant(c.Request.Context(), variant, payload.Attributes)
 	if err != nil {
 		logger.Errorf("Error creating product variant: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeVariantError(c, err)
 		return
 	}
 
@@ -117,7 +170,7 @@ func (h *ProductVariantHandler) GetProductVariantByID(c *gin.Context) {
 		return
 	}
 
-	variant, err := h.productVariantSvc.GetProductVariantByID(uint(id))
+	variant, err := h.productVariantSvc.GetProductVariantByID(c.Request.Context(), uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -143,7 +196,7 @@ func (h *ProductVariantHandler) GetProductVariantBySKU(c *gin.Context) {
 		return
 	}
 
-	variant, err := h.productVariantSvc.GetBySKU(sku)
+	variant, err := h.productVariantSvc.GetBySKU(c.Request.Context(), sku)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -167,6 +220,9 @@ func (h *ProductVariantHandler) GetProductVariantBySKU(c *gin.Context) {
 // @Failure 400 {object} map[string]string "Неверное тело запроса"
 // @Failure 404 {object} map[string]string "Вариант продукта не найден"
 // @Failure 500 {object} map[string]string "Ошибка при обновлении варианта продукта"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Продукт принадлежит другому продавцу"
+// @Security ApiKeyAuth
 // @Router /product-variants/{id} [put]
 func (h *ProductVariantHandler) UpdateProductVariant(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -181,10 +237,10 @@ func (h *ProductVariantHandler) UpdateProductVariant(c *gin.Context) {
 		return
 	}
 
-	updated, err := h.productVariantSvc.UpdateProductVariantByInput(uint(id), payload)
+	updated, err := h.productVariantSvc.UpdateProductVariantByInput(c.Request.Context(), uint(id), payload)
 	if err != nil {
 		logger.Errorf("Error updating product variant: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeVariantError(c, err)
 		return
 	}
 
@@ -213,6 +269,9 @@ func (h *ProductVariantHandler) UpdateProductVariant(c *gin.Context) {
 // @Success 200 {object} map[string]string "Вариант продукта удален"
 // @Failure 400 {object} map[string]string "Неверный ID варианта продукта"
 // @Failure 500 {object} map[string]string "Ошибка при удалении варианта продукта"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Продукт принадлежит другому продавцу"
+// @Security ApiKeyAuth
 // @Router /product-variants/{id} [delete]
 func (h *ProductVariantHandler) DeleteProductVariant(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -221,8 +280,8 @@ func (h *ProductVariantHandler) DeleteProductVariant(c *gin.Context) {
 		return
 	}
 
-	if err := h.productVariantSvc.DeleteProductVariant(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.productVariantSvc.DeleteProductVariant(c.Request.Context(), uint(id)); err != nil {
+		writeVariantError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "product variant deleted"})
@@ -230,68 +289,132 @@ func (h *ProductVariantHandler) DeleteProductVariant(c *gin.Context) {
 
 // ReserveStock резервирует товар на складе.
 // @Summary Резервирование товара
-// @Description Резервирует указанное количество товара для варианта продукта.
+// @Description Создаёт бронь указанного количества товара под заказ. Бронь истекает через ttl_seconds (или TTL по умолчанию), если её не подтвердить.
 // @Tags Варианты Продуктов
 // @Accept json
 // @Produce json
 // @Param id path int true "ID варианта продукта"
-// @Param quantity body ReserveStockPayload true "Количество для резервирования"
-// @Success 200 {object} map[string]string "Запас зарезервирован"
-// @Failure 400 {object} map[string]string "Неверное количество"
-// @Failure 409 {object} map[string]string "Ошибка при резервировании товара"
+// @Param reservation body ReserveStockPayload true "Количество, ID заказа и TTL брони"
+// @Success 201 {object} Reservation
+// @Failure 400 {object} map[string]string "Неверное тело запроса"
+// @Failure 409 {object} map[string]interface{} "Недостаточно товара или вариант не найден (shortfalls)"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Только внутренние сервисы"
+// @Security ApiKeyAuth
 // @Router /product-variants/{id}/reserve [post]
 func (h *ProductVariantHandler) ReserveStock(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
+	if err != nil || id <= 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid variant id"})
 		return
 	}
 
 	var payload ReserveStockPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quantity"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	reservation, err := h.reservationSvc.Reserve(
+		c.Request.Context(),
+		payload.OrderID,
+		[]ReservationItem{{ProductVariantID: uint(id), Quantity: payload.Quantity}},
+		time.Duration(payload.TTLSeconds)*time.Second,
+	)
+	if err != nil {
+		writeReservationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, reservation)
+}
+
+// ReserveStockBatch бронирует всю корзину целиком.
+// @Summary Резервирование корзины
+// @Description Атомарно бронирует все позиции под один заказ: либо все, либо ни одной. При нехватке возвращает список строк с недостачей.
+// @Tags Варианты Продуктов
+// @Accept json
+// @Produce json
+// @Param reservation body ReserveBatchPayload true "ID заказа, TTL и позиции корзины"
+// @Success 201 {object} Reservation
+// @Failure 400 {object} map[string]string "Неверное тело запроса"
+// @Failure 409 {object} map[string]interface{} "Недостаточно товара (shortfalls)"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Только внутренние сервисы"
+// @Security ApiKeyAuth
+// @Router /product-variants/reserve [post]
+func (h *ProductVariantHandler) ReserveStockBatch(c *gin.Context) {
+	var payload ReserveBatchPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
 		return
 	}
 
-	if err := h.productVariantSvc.ReserveStock(uint(id), payload.Quantity); err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	items := make([]ReservationItem, len(payload.Items))
+	for i, line := range payload.Items {
+		items[i] = ReservationItem{ProductVariantID: line.ProductVariantID, Quantity: line.Quantity}
+	}
+
+	reservation, err := h.reservationSvc.Reserve(c.Request.Context(), payload.OrderID, items, time.Duration(payload.TTLSeconds)*time.Second)
+	if err != nil {
+		writeReservationError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "stock reserved"})
+	c.JSON(http.StatusCreated, reservation)
 }
 
 // ReleaseStock освобождает зарезервированный товар.
 // @Summary Освобождение товара
-// @Description Освобождает зарезервированное количество товара для варианта продукта.
+// @Description Отменяет бронь одного варианта и возвращает её товар в свободный остаток. Бронь корзины из нескольких вариантов отменяется через /reservations/{id}/cancel.
 // @Tags Варианты Продуктов
 // @Accept json
 // @Produce json
 // @Param id path int true "ID варианта продукта"
-// @Param quantity body ReleaseStockPayload true "Количество для освобождения"
-// @Success 200 {object} map[string]string "Запас освобожден"
-// @Failure 400 {object} map[string]string "Неверное количество"
-// @Failure 500 {object} map[string]string "Ошибка при освобождении товара"
+// @Param reservation body ReleaseStockPayload true "ID брони"
+// @Success 200 {object} Reservation
+// @Failure 400 {object} map[string]string "Неверное тело запроса"
+// @Failure 404 {object} map[string]string "Бронь не найдена"
+// @Failure 409 {object} map[string]string "Бронь уже снята или держит несколько вариантов"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Только внутренние сервисы"
+// @Security ApiKeyAuth
 // @Router /product-variants/{id}/release [post]
 func (h *ProductVariantHandler) ReleaseStock(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
+	if err != nil || id <= 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid variant id"})
 		return
 	}
 
 	var payload ReleaseStockPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quantity"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
 		return
 	}
 
-	if err := h.productVariantSvc.ReleaseStock(uint(id), payload.Quantity); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	reservation, err := h.reservationSvc.GetReservation(c.Request.Context(), payload.ReservationID)
+	if err != nil {
+		writeReservationError(c, err)
+		return
+	}
+	if !reservation.HasVariant(uint(id)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "reservation does not hold this variant"})
+		return
+	}
+	// Позицию брони по отдельности не снять: отмена вернула бы в остаток и чужие варианты
+	if len(reservation.Items) > 1 {
+		c.JSON(http.StatusConflict, gin.H{"error": "reservation holds several variants, cancel it via /reservations/{id}/cancel"})
+		return
+	}
+
+	released, err := h.reservationSvc.Cancel(c.Request.Context(), reservation.ID)
+	if err != nil {
+		writeReservationError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "stock released"})
+	c.JSON(http.StatusOK, released)
 }
 
 // UpdateStock обновляет запас товара.
@@ -304,7 +427,12 @@ func (h *ProductVariantHandler) ReleaseStock(c *gin.Context) {
 // @Param stock body UpdateStockPayload true "Новое количество товара"
 // @Success 200 {object} map[string]string "Запас обновлен"
 // @Failure 400 {object} map[string]string "Неверное количество товара"
+// @Failure 404 {object} map[string]string "Вариант продукта не найден"
+// @Failure 409 {object} map[string]string "Новый запас меньше забронированного"
 // @Failure 500 {object} map[string]string "Ошибка при обновлении запаса"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Продукт принадлежит другому продавцу"
+// @Security ApiKeyAuth
 // @Router /product-variants/{id}/stock [put]
 func (h *ProductVariantHandler) UpdateStock(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -319,8 +447,8 @@ func (h *ProductVariantHandler) UpdateStock(c *gin.Context) {
 		return
 	}
 
-	if err := h.productVariantSvc.UpdateStock(uint(id), payload.Stock); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.productVariantSvc.UpdateStock(c.Request.Context(), uint(id), *payload.Stock); err != nil {
+		writeVariantError(c, err)
 		return
 	}
 
@@ -336,6 +464,7 @@ func (h *ProductVariantHandler) UpdateStock(c *gin.Context) {
 // @Param id path int true "ID варианта продукта"
 // @Success 200 {object} map[string]int "Доступный запас"
 // @Failure 400 {object} map[string]string "Неверный ID варианта продукта"
+// @Failure 404 {object} map[string]string "Вариант продукта не найден"
 // @Failure 500 {object} map[string]string "Ошибка при получении доступного запаса"
 // @Router /product-variants/{id}/available [get]
 func (h *ProductVariantHandler) GetAvailableStock(c *gin.Context) {
@@ -345,9 +474,9 @@ func (h *ProductVariantHandler) GetAvailableStock(c *gin.Context) {
 		return
 	}
 
-	available, err := h.productVariantSvc.GetAvailableStock(uint(id))
+	available, err := h.productVariantSvc.GetAvailableStock(c.Request.Context(), uint(id))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeVariantError(c, err)
 		return
 	}
 
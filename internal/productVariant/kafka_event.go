@@ -1,13 +1,19 @@
 package productVariant
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/product-service/internal/auth"
+	"github.com/ShopOnGO/product-service/internal/outbox"
 )
 
-func HandleProductVariantEvent(msg []byte, key string, productVariantSvc *ProductVariantService) error {
+// productsProducer — события вариантов уходят в топик продуктов, который слушают Search и Media.
+const productsProducer = "products"
+
+func HandleProductVariantEvent(ctx context.Context, msg []byte, key string, productVariantSvc *ProductVariantService) error {
 	logger.Infof("Получено сообщение для варианта продукта: %s", string(msg))
 
 	var base BaseProductVariantEvent
@@ -15,10 +21,10 @@ func HandleProductVariantEvent(msg []byte, key string, productVariantSvc *Produc
 		return fmt.Errorf("ошибка десериализации базового сообщения варианта: %w", err)
 	}
 
-	eventHandlers := map[string]func([]byte, *ProductVariantService) error{
+	eventHandlers := map[string]func(context.Context, []byte, *ProductVariantService) error{
 		"create": HandleCreateProductVariantEvent,
-		// "update": HandleUpdateProductVariantEvent,
-		// "delete": HandleDeleteProductVariantEvent,
+		"update": HandleUpdateProductVariantEvent,
+		"delete": HandleDeleteProductVariantEvent,
 	}
 
 	handler, exists := eventHandlers[base.Action]
@@ -26,11 +32,11 @@ func HandleProductVariantEvent(msg []byte, key string, productVariantSvc *Produc
 		return fmt.Errorf("неизвестное действие для варианта продукта: %s", base.Action)
 	}
 
-	return handler(msg, productVariantSvc)
+	return handler(ctx, msg, productVariantSvc)
 }
 
 // HandleCreateProductVariantEvent обрабатывает создание варианта продукта
-func HandleCreateProductVariantEvent(msg []byte, productVariantSvc *ProductVariantService) error {
+func HandleCreateProductVariantEvent(ctx context.Context, msg []byte, productVariantSvc *ProductVariantService) error {
 	var base BaseProductVariantEvent
 	if err := json.Unmarshal(msg, &base); err != nil {
 		return fmt.Errorf("ошибка десериализации базового сообщения варианта: %w", err)
@@ -38,16 +44,13 @@ func HandleCreateProductVariantEvent(msg []byte, productVariantSvc *ProductVaria
 
 	event := base.ProductVariant
 	logger.Infof(
-        "Создание варианта (prod=%d) от seller %d: SKU=%q, Price=%s, Discount=%s, Stock=%d, IsActive=%t",
-        base.ProductID, base.UserID,
-        event.SKU, event.Price.String(), event.Discount.String(), event.Stock, event.IsActive,
-    )
-
-	// if err := productVariantSvc.CheckProductOwnership(base.ProductID, base.UserID); err != nil {
-	// 	logger.Warnf("Проверка прав доступа отклонена: %v", err)
-	// 	return err
-	// }
-	
+		"Создание варианта (prod=%d) от seller %d: SKU=%q, Price=%s, Discount=%s, Stock=%d, IsActive=%t",
+		base.ProductID, base.UserID,
+		event.SKU, event.Price.String(), event.Discount.String(), event.Stock, event.IsActive,
+	)
+
+	ctx = sellerContext(ctx, base.UserID)
+
 	newProductVariant := &ProductVariant{
 		ProductID:  base.ProductID,
 		SKU:        event.SKU,
@@ -63,7 +66,7 @@ func HandleCreateProductVariantEvent(msg []byte, productVariantSvc *ProductVaria
 		Dimensions: event.Dimensions,
 	}
 
-	created, err := productVariantSvc.CreateProductVariant(newProductVariant)
+	created, err := productVariantSvc.CreateProductVariant(ctx, newProductVariant, event.Attributes)
 	if err != nil {
 		logger.Errorf("Ошибка при создании варианта продукта: %v", err)
 		return err
@@ -71,4 +74,90 @@ func HandleCreateProductVariantEvent(msg []byte, productVariantSvc *ProductVaria
 
 	logger.Infof("Вариант продукта успешно создан: %+v", created)
 	return nil
-}
\ No newline at end of file
+}
+
+// HandleUpdateProductVariantEvent частично обновляет вариант продукта
+func HandleUpdateProductVariantEvent(ctx context.Context, msg []byte, productVariantSvc *ProductVariantService) error {
+	var base BaseProductVariantUpdateEvent
+	if err := json.Unmarshal(msg, &base); err != nil {
+		return fmt.Errorf("ошибка десериализации события обновления варианта: %w", err)
+	}
+
+	logger.Infof("Обновление варианта ID %d пользователем %d", base.ProductVariantID, base.UserID)
+	ctx = sellerContext(ctx, base.UserID)
+
+	updated, err := productVariantSvc.UpdateProductVariantByInput(ctx, base.ProductVariantID, base.ProductVariant)
+	if err != nil {
+		logger.Errorf("Ошибка при обновлении варианта продукта: %v", err)
+		return err
+	}
+	logger.Infof("Вариант продукта ID %d успешно обновлён", updated.ID)
+	return nil
+}
+
+// HandleDeleteProductVariantEvent мягко удаляет вариант продукта
+func HandleDeleteProductVariantEvent(ctx context.Context, msg []byte, productVariantSvc *ProductVariantService) error {
+	var base BaseProductVariantDeleteEvent
+	if err := json.Unmarshal(msg, &base); err != nil {
+		return fmt.Errorf("ошибка десериализации события удаления варианта: %w", err)
+	}
+
+	logger.Infof("Удаление варианта ID %d пользователем %d", base.ProductVariantID, base.UserID)
+	ctx = sellerContext(ctx, base.UserID)
+
+	if err := productVariantSvc.DeleteProductVariant(ctx, base.ProductVariantID); err != nil {
+		logger.Errorf("Ошибка при удалении варианта продукта: %v", err)
+		return err
+	}
+	logger.Infof("Вариант продукта ID %d удалён", base.ProductVariantID)
+	return nil
+}
+
+// sellerContext: изменения из Kafka выполняются от имени продавца из user_id события.
+func sellerContext(ctx context.Context, userID uint) context.Context {
+	if userID == 0 {
+		return ctx
+	}
+	return auth.WithActor(ctx, auth.Actor{UserID: userID, Role: auth.RoleSeller})
+}
+
+// variantEvent строит событие outbox по уже записанному варианту.
+type variantEvent func(*ProductVariant) outbox.Event
+
+func variantUpdatedEvent(v *ProductVariant) outbox.Event {
+	return outbox.Event{Producer: productsProducer, Key: "variant-updated", Payload: ProductVariantChangedEvent{
+		Action:    "update",
+		ProductID: v.ProductID,
+		VariantID: v.ID,
+		Variant:   ConvertVariantToEvent(v),
+	}}
+}
+
+func variantDeletedEvent(v *ProductVariant) outbox.Event {
+	return outbox.Event{Producer: productsProducer, Key: "variant-deleted", Payload: ProductVariantChangedEvent{
+		Action:    "delete",
+		ProductID: v.ProductID,
+		VariantID: v.ID,
+	}}
+}
+
+func ConvertVariantToEvent(v *ProductVariant) *ProductVariantForEvent {
+	return &ProductVariantForEvent{
+		VariantID:        v.ID,
+		SKU:              v.SKU,
+		Price:            v.Price.InexactFloat64(),
+		Discount:         v.Discount.InexactFloat64(),
+		CampaignDiscount: v.CampaignDiscount.InexactFloat64(),
+		EffectivePrice:   v.EffectivePrice.InexactFloat64(),
+		Sizes:            v.Sizes,
+		Colors:           v.Colors,
+		Stock:            v.Stock,
+		Barcode:          v.Barcode,
+		Dimensions:       v.Dimensions,
+		Images:           v.ImageURLs,
+		MinOrder:         v.MinOrder,
+		IsActive:         v.IsActive,
+		ReservedStock:    v.ReservedStock,
+		Attributes:       v.Attributes,
+	}
+}
@@ -0,0 +1,129 @@
+package productVariant
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	defaultSort     = "created_at"
+)
+
+var (
+	ErrInvalidSort      = errors.New("invalid sort field")
+	ErrInvalidPriceSpan = errors.New("invalid price range")
+)
+
+// variantSorts — допустимые ключи сортировки и их SQL-выражения.
+var variantSorts = map[string]string{
+	"created_at":      "product_variants.created_at",
+	"price":           "product_variants.price",
+	"effective_price": "product_variants.effective_price",
+	"discount":        "(product_variants.discount + product_variants.campaign_discount)",
+	"stock":           "(product_variants.stock - product_variants.reserved_stock)",
+}
+
+// variantListParams — провалидированные параметры поиска вариантов для репозитория.
+type variantListParams struct {
+	sortExpr string
+	desc     bool
+	page     int
+	pageSize int
+
+	productID   uint
+	minPrice    *decimal.Decimal
+	maxPrice    *decimal.Decimal
+	size        string
+	color       string
+	inStock     bool
+	isActive    *bool
+	hasDiscount bool
+}
+
+func (p *variantListParams) offset() int {
+	return (p.page - 1) * p.pageSize
+}
+
+func newVariantListParams(q VariantListQuery) (*variantListParams, error) {
+	p := &variantListParams{
+		page:        q.Page,
+		pageSize:    q.PageSize,
+		productID:   q.ProductID,
+		size:        normalizeFilterValue(q.Size),
+		color:       normalizeFilterValue(q.Color),
+		inStock:     q.InStock,
+		isActive:    q.IsActive,
+		hasDiscount: q.HasDiscount,
+	}
+
+	if p.pageSize <= 0 {
+		p.pageSize = defaultPageSize
+	}
+	if p.pageSize > maxPageSize {
+		p.pageSize = maxPageSize
+	}
+	if p.page <= 0 {
+		p.page = 1
+	}
+
+	sortName := strings.ToLower(q.Sort)
+	if sortName == "" {
+		sortName = defaultSort
+	}
+	expr, ok := variantSorts[sortName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSort, q.Sort)
+	}
+	p.sortExpr = expr
+
+	switch strings.ToLower(q.Order) {
+	case "", "desc":
+		p.desc = true
+	case "asc":
+		p.desc = false
+	default:
+		return nil, fmt.Errorf("%w: order must be asc or desc", ErrInvalidSort)
+	}
+
+	var err error
+	if p.minPrice, err = parsePrice(q.MinPrice); err != nil {
+		return nil, err
+	}
+	if p.maxPrice, err = parsePrice(q.MaxPrice); err != nil {
+		return nil, err
+	}
+	if p.minPrice != nil && p.maxPrice != nil && p.minPrice.GreaterThan(*p.maxPrice) {
+		return nil, fmt.Errorf("%w: min_price is greater than max_price", ErrInvalidPriceSpan)
+	}
+	return p, nil
+}
+
+func parsePrice(s string) (*decimal.Decimal, error) {
+	if s == "" {
+		return nil, nil
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil || d.IsNegative() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPriceSpan, s)
+	}
+	return &d, nil
+}
+
+// normalizeFilterValue приводит значение фильтра к виду, в котором сравниваются sizes и colors.
+func normalizeFilterValue(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// orderClause сортирует по выбранному ключу с ID в качестве тай-брейкера, чтобы страницы не пересекались.
+func (p *variantListParams) orderClause() string {
+	dir := "ASC"
+	if p.desc {
+		dir = "DESC"
+	}
+	return fmt.Sprintf("%s %s, product_variants.id %s", p.sortExpr, dir, dir)
+}
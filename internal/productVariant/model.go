@@ -1,6 +1,7 @@
 package productVariant
 
 import (
+	"github.com/ShopOnGO/product-service/internal/attribute"
 	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
@@ -8,18 +9,30 @@ import (
 
 type ProductVariant struct {
 	gorm.Model
-	ProductID		uint      			`gorm:"index;not null"`                // на всякий
-	SKU           	string    			`gorm:"type:varchar(100);uniqueIndex"` // Уникальный артикул
-	Price    	  	decimal.Decimal 	`gorm:"type:decimal(8,2);not null"`
-	Discount 	  	decimal.Decimal 	`gorm:"type:decimal(8,2);not null;default:0"`
-	ReservedStock 	uint32    			`gorm:"not null"` // бронь (пока оплатишь типа)
-	Sizes  			string 				`gorm:"type:varchar(255)" json:"sizes"`
-	Colors 			string 				`gorm:"type:varchar(255)" json:"colors"`
-	Stock         	uint32    			`gorm:"default:0"`         // Общий остаток на складе
-	Barcode    	  	string   			`gorm:"type:varchar(50)"`  // Штрих-код
-	IsActive   		bool     			`gorm:"default:true"`      // Активен ли вариант
-	ImageURLs 		pq.StringArray 		`gorm:"type:text[]" json:"images"` 
-	MinOrder   		uint     			`gorm:"default:1"`         // Минимальный заказ
-	Dimensions 		string   			`gorm:"type:varchar(50)"`  // Габариты (например "20x30x5 см")
+	ProductID        uint            `gorm:"index;not null"`                // на всякий
+	SKU              string          `gorm:"type:varchar(100);uniqueIndex"` // Уникальный артикул
+	Price            decimal.Decimal `gorm:"type:decimal(8,2);not null"`
+	Discount         decimal.Decimal `gorm:"type:decimal(8,2);not null;default:0"`
+	CampaignDiscount decimal.Decimal `gorm:"type:decimal(8,2);not null;default:0"`                                                 // скидка действующих акций, её ведёт пакет campaign
+	EffectivePrice   decimal.Decimal `gorm:"type:decimal(8,2);not null;default:0"`                                                 // цена покупателя: Price - Discount - CampaignDiscount
+	ReservedStock    uint32          `gorm:"not null;default:0;check:chk_product_variants_reserved_stock,reserved_stock <= stock"` // бронь (пока оплатишь типа)
+	Sizes            string          `gorm:"type:varchar(255)" json:"sizes"`
+	Colors           string          `gorm:"type:varchar(255)" json:"colors"`
+	Stock            uint32          `gorm:"default:0"`        // Общий остаток на складе
+	Barcode          string          `gorm:"type:varchar(50)"` // Штрих-код
+	IsActive         bool            `gorm:"default:true"`     // Активен ли вариант
+	ImageURLs        pq.StringArray  `gorm:"type:text[]" json:"images"`
+	MinOrder         uint            `gorm:"default:1"`        // Минимальный заказ
+	Dimensions       string          `gorm:"type:varchar(50)"` // Габариты (например "20x30x5 см")
+
+	// Типизированные атрибуты по определениям категории; Sizes, Colors и Dimensions пересобираются из них.
+	Attributes []attribute.VariantValue `gorm:"foreignKey:VariantID" json:"attributes,omitempty"`
 }
 
+// AvailableStock — свободный остаток: общий сток за вычетом брони.
+func (v *ProductVariant) AvailableStock() uint32 {
+	if v.ReservedStock >= v.Stock {
+		return 0
+	}
+	return v.Stock - v.ReservedStock
+}
@@ -1,73 +1,156 @@
 package productVariant
 
 import (
+	"github.com/ShopOnGO/product-service/internal/attribute"
 	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
 )
 
 type CreateProductVariantPayload struct {
-	ProductID     uint     			`json:"product_id" binding:"required"`
-	SKU           string   			`json:"sku" binding:"required"`
-	Price    	  decimal.Decimal   `json:"price" binding:"required"`
-	Discount 	  decimal.Decimal   `json:"discount"`
-	ReservedStock uint32   			`json:"reserved_stock"`
-	Sizes  		  string 			`json:"sizes" binding:"omitempty"`
-	Colors        string 			`json:"colors" binding:"omitempty"`
-	Stock         uint32   			`json:"stock"`
-	Material      string   			`json:"material"`
-	Barcode       string   			`json:"barcode"`
-	IsActive      bool     			`json:"is_active"`
-	Images        []string 			`json:"images" binding:"omitempty"`
-	MinOrder      uint     			`json:"min_order"`
-	Dimensions    string   			`json:"dimensions"`
+	ProductID  uint                   `json:"product_id" binding:"required"`
+	SKU        string                 `json:"sku" binding:"required"`
+	Price      decimal.Decimal        `json:"price" binding:"required"`
+	Discount   decimal.Decimal        `json:"discount"`
+	Sizes      string                 `json:"sizes" binding:"omitempty"`
+	Colors     string                 `json:"colors" binding:"omitempty"`
+	Stock      uint32                 `json:"stock"`
+	Material   string                 `json:"material"`
+	Barcode    string                 `json:"barcode"`
+	IsActive   bool                   `json:"is_active"`
+	Images     []string               `json:"images" binding:"omitempty"`
+	MinOrder   uint                   `json:"min_order"`
+	Dimensions string                 `json:"dimensions"`
+	Attributes []attribute.ValueInput `json:"attributes"` // если заданы, заменяют sizes, colors и dimensions
 }
 
 type ProductVariantCreatedEvent struct {
-	SKU           string   			`json:"sku" binding:"required"`
-	Price    	  decimal.Decimal   `json:"price" binding:"required"`
-	Discount 	  decimal.Decimal   `json:"discount"`
-	ReservedStock uint32   			`json:"reserved_stock"`
-	Sizes  		  string 			`json:"sizes" binding:"omitempty"`
-	Colors        string 			`json:"colors" binding:"omitempty"`
-	Stock         uint32   			`json:"stock"`
-	Material      string   			`json:"material"`
-	Barcode       string   			`json:"barcode"`
-	IsActive      bool     			`json:"is_active"`
-	Images        []string 			`json:"images" binding:"omitempty"`
-	MinOrder      uint     			`json:"min_order"`
-	Dimensions    string   			`json:"dimensions"`
+	SKU           string                 `json:"sku" binding:"required"`
+	Price         decimal.Decimal        `json:"price" binding:"required"`
+	Discount      decimal.Decimal        `json:"discount"`
+	ReservedStock uint32                 `json:"reserved_stock"`
+	Sizes         string                 `json:"sizes" binding:"omitempty"`
+	Colors        string                 `json:"colors" binding:"omitempty"`
+	Stock         uint32                 `json:"stock"`
+	Material      string                 `json:"material"`
+	Barcode       string                 `json:"barcode"`
+	IsActive      bool                   `json:"is_active"`
+	Images        []string               `json:"images" binding:"omitempty"`
+	MinOrder      uint                   `json:"min_order"`
+	Dimensions    string                 `json:"dimensions"`
+	Attributes    []attribute.ValueInput `json:"attributes"`
 }
 
+// EventID во всех конвертах — ключ идемпотентности: повторная доставка события с тем же ID игнорируется.
 type BaseProductVariantEvent struct {
-	Action  		string                   	`json:"action"`
-	ProductID		uint					 	`json:"product_id"`
-	ProductVariant 	ProductVariantCreatedEvent 	`json:"product_variant"`
-	UserID 			uint                     	`json:"user_id"`
+	EventID        string                     `json:"event_id"`
+	Action         string                     `json:"action"`
+	ProductID      uint                       `json:"product_id"`
+	ProductVariant ProductVariantCreatedEvent `json:"product_variant"`
+	UserID         uint                       `json:"user_id"`
 }
 
 type UpdateProductVariantPayload struct {
-	Price    	  *decimal.Decimal 	`json:"price" gorm:"type:decimal(8,2);not null"`
-	Discount 	  *decimal.Decimal 	`json:"discount" gorm:"type:decimal(8,2);not null;default:0"`
-	ReservedStock *uint32          	`json:"reserved_stock"`
-	Sizes         *string        	`json:"sizes"`
-	Colors        *string        	`json:"colors"`
-	Stock         *uint32          	`json:"stock"`
-	Material      *string          	`json:"material"`
-	Barcode       *string          	`json:"barcode"`
-	IsActive      *bool            	`json:"is_active"`
-	ImageURLs 	  *pq.StringArray 	`gorm:"type:text[]"`
-	MinOrder      *uint            	`json:"min_order"`
-	Dimensions    *string          	`json:"dimensions"`
+	Price      *decimal.Decimal        `json:"price" gorm:"type:decimal(8,2);not null"`
+	Discount   *decimal.Decimal        `json:"discount" gorm:"type:decimal(8,2);not null;default:0"`
+	Sizes      *string                 `json:"sizes"`
+	Colors     *string                 `json:"colors"`
+	Stock      *uint32                 `json:"stock"`
+	Material   *string                 `json:"material"`
+	Barcode    *string                 `json:"barcode"`
+	IsActive   *bool                   `json:"is_active"`
+	ImageURLs  *pq.StringArray         `gorm:"type:text[]"`
+	MinOrder   *uint                   `json:"min_order"`
+	Dimensions *string                 `json:"dimensions"`
+	Attributes *[]attribute.ValueInput `json:"attributes"` // заменяет все атрибуты варианта
+}
+
+// VariantListQuery — фильтры, сортировка и пагинация поиска вариантов.
+type VariantListQuery struct {
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+	Sort     string `form:"sort"`  // created_at, price, effective_price, discount, stock
+	Order    string `form:"order"` // asc, desc
+
+	ProductID   uint   `form:"product_id"`
+	MinPrice    string `form:"min_price"`
+	MaxPrice    string `form:"max_price"`
+	Size        string `form:"size"`  // одно из значений sizes, без учёта регистра
+	Color       string `form:"color"` // одно из значений colors, без учёта регистра
+	InStock     bool   `form:"in_stock"`
+	IsActive    *bool  `form:"is_active"`
+	HasDiscount bool   `form:"has_discount"`
+}
+
+type VariantPage struct {
+	Items    []ProductVariant `json:"items"`
+	Total    int64            `json:"total"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
 }
 
 type ReserveStockPayload struct {
-	Quantity uint32 `json:"quantity" binding:"required,gt=0"`
+	Quantity   uint32 `json:"quantity" binding:"required,gt=0"`
+	OrderID    string `json:"order_id" binding:"required"`
+	TTLSeconds uint32 `json:"ttl_seconds"` // 0 — TTL по умолчанию
+}
+
+type ReserveBatchLine struct {
+	ProductVariantID uint   `json:"product_variant_id" binding:"required"`
+	Quantity         uint32 `json:"quantity" binding:"required,gt=0"`
+}
+
+type ReserveBatchPayload struct {
+	OrderID    string             `json:"order_id" binding:"required"`
+	TTLSeconds uint32             `json:"ttl_seconds"` // 0 — TTL по умолчанию
+	Items      []ReserveBatchLine `json:"items" binding:"required,min=1,dive"`
 }
 
 type ReleaseStockPayload struct {
-	Quantity uint32 `json:"quantity" binding:"required,gt=0"`
+	ReservationID string `json:"reservation_id" binding:"required"`
 }
 
 type UpdateStockPayload struct {
-	Stock uint32 `json:"stock" binding:"required"`
-}
\ No newline at end of file
+	Stock *uint32 `json:"stock" binding:"required"` // указатель, чтобы можно было обнулить сток
+}
+
+type ProductVariantForEvent struct {
+	VariantID        uint                     `json:"variant_id"`
+	SKU              string                   `json:"sku"`
+	Price            float64                  `json:"price"`
+	Discount         float64                  `json:"discount"`
+	CampaignDiscount float64                  `json:"campaign_discount"`
+	EffectivePrice   float64                  `json:"effective_price"`
+	Sizes            string                   `json:"sizes"`
+	Colors           string                   `json:"colors"`
+	Stock            uint32                   `json:"stock"`
+	Barcode          string                   `json:"barcode,omitempty"`
+	Dimensions       string                   `json:"dimensions,omitempty"`
+	Images           []string                 `json:"images,omitempty"`
+	MinOrder         uint                     `json:"min_order,omitempty"`
+	IsActive         bool                     `json:"is_active"`
+	ReservedStock    uint32                   `json:"reserved_stock,omitempty"`
+	Attributes       []attribute.VariantValue `json:"attributes,omitempty"`
+}
+
+type BaseProductVariantUpdateEvent struct {
+	EventID          string                      `json:"event_id"`
+	Action           string                      `json:"action"`
+	UserID           uint                        `json:"user_id"`
+	ProductVariantID uint                        `json:"product_variant_id"`
+	ProductVariant   UpdateProductVariantPayload `json:"product_variant"`
+}
+
+type BaseProductVariantDeleteEvent struct {
+	EventID          string `json:"event_id"`
+	Action           string `json:"action"`
+	UserID           uint   `json:"user_id"`
+	ProductVariantID uint   `json:"product_variant_id"`
+}
+
+// ProductVariantChangedEvent — уведомление Search/Media об изменении или удалении варианта.
+type ProductVariantChangedEvent struct {
+	Action    string                  `json:"action"`
+	ProductID uint                    `json:"product_id"`
+	VariantID uint                    `json:"variant_id"`
+	Variant   *ProductVariantForEvent `json:"variant,omitempty"`
+}
@@ -0,0 +1,115 @@
+package productVariant
+
+import (
+	"time"
+
+	"github.com/ShopOnGO/product-service/internal/campaign"
+	"github.com/ShopOnGO/product-service/internal/outbox"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// repriceBatchSize ограничивает число вариантов, читаемых за один запрос при пересчёте цен.
+const repriceBatchSize = 500
+
+// Reprice реализует interfaces.VariantRepricer: пересчитывает цены вариантов по акциям,
+// действующим в момент now, и пишет variant-updated в outbox для изменившихся.
+func (repo *ProductVariantRepository) Reprice(tx *gorm.DB, variantIDs []uint, now time.Time) (int, error) {
+	changed := 0
+	for start := 0; start < len(variantIDs); start += repriceBatchSize {
+		end := start + repriceBatchSize
+		if end > len(variantIDs) {
+			end = len(variantIDs)
+		}
+		variants, changedIdx, err := reprice(tx, variantIDs[start:end], now)
+		if err != nil {
+			return changed, err
+		}
+		events := make([]outbox.Event, 0, len(changedIdx))
+		for _, i := range changedIdx {
+			events = append(events, variantUpdatedEvent(&variants[i]))
+		}
+		if err := outbox.Enqueue(tx, events...); err != nil {
+			return changed, err
+		}
+		changed += len(changedIdx)
+	}
+	return changed, nil
+}
+
+// ApplyCampaigns пересчитывает цены только что записанных вариантов в транзакции tx
+// и переносит скидку акций и итоговую цену в variants.
+func ApplyCampaigns(tx *gorm.DB, variants []ProductVariant, now time.Time) error {
+	if len(variants) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(variants))
+	for i := range variants {
+		ids[i] = variants[i].ID
+	}
+	repriced, _, err := reprice(tx, ids, now)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uint]*ProductVariant, len(repriced))
+	for i := range repriced {
+		byID[repriced[i].ID] = &repriced[i]
+	}
+	for i := range variants {
+		if r, ok := byID[variants[i].ID]; ok {
+			variants[i].CampaignDiscount = r.CampaignDiscount
+			variants[i].EffectivePrice = r.EffectivePrice
+		}
+	}
+	return nil
+}
+
+// reapplyCampaigns пересчитывает цену одного записанного варианта.
+func reapplyCampaigns(tx *gorm.DB, variant *ProductVariant) error {
+	repriced, _, err := reprice(tx, []uint{variant.ID}, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(repriced) > 0 {
+		variant.CampaignDiscount, variant.EffectivePrice = repriced[0].CampaignDiscount, repriced[0].EffectivePrice
+	}
+	return nil
+}
+
+// reprice читает варианты ids, считает для них скидку действующих акций и итоговую цену
+// и сохраняет те, у которых они изменились. Возвращает варианты с новыми ценами
+// и индексы изменившихся.
+func reprice(tx *gorm.DB, ids []uint, now time.Time) ([]ProductVariant, []int, error) {
+	var variants []ProductVariant
+	if err := tx.Preload("Attributes").Where("id IN ?", ids).Order("id").Find(&variants).Error; err != nil {
+		return nil, nil, err
+	}
+
+	bases := make(map[uint]decimal.Decimal, len(variants))
+	for _, v := range variants {
+		bases[v.ID] = campaign.BasePrice(v.Price, v.Discount)
+	}
+	discounts, err := campaign.Discounts(tx, bases, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var changed []int
+	for i := range variants {
+		v := &variants[i]
+		discount := discounts[v.ID]
+		effective := campaign.EffectivePrice(v.Price, v.Discount, discount)
+		if discount.Equal(v.CampaignDiscount) && effective.Equal(v.EffectivePrice) {
+			continue
+		}
+		if err := tx.Model(&ProductVariant{}).Where("id = ?", v.ID).Updates(map[string]interface{}{
+			"campaign_discount": discount,
+			"effective_price":   effective,
+		}).Error; err != nil {
+			return nil, nil, err
+		}
+		v.CampaignDiscount, v.EffectivePrice = discount, effective
+		changed = append(changed, i)
+	}
+	return variants, changed, nil
+}
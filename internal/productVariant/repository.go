@@ -1,13 +1,21 @@
 package productVariant
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sort"
 
+	"github.com/ShopOnGO/product-service/internal/attribute"
+	"github.com/ShopOnGO/product-service/internal/outbox"
 	"github.com/ShopOnGO/product-service/pkg/db"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var ErrStockBelowReserved = errors.New("stock cannot be set below reserved stock")
+
 type ProductVariantRepository struct {
 	Database *db.Db
 }
@@ -18,17 +26,23 @@ func NewProductVariantRepository(database *db.Db) *ProductVariantRepository {
 	}
 }
 
-func (repo *ProductVariantRepository) Create(variant *ProductVariant) (*ProductVariant, error) {
-	result := repo.Database.DB.Create(variant)
-	if result.Error != nil {
-		return nil, result.Error
+// Create сохраняет вариант и сразу считает его цену по действующим акциям.
+func (repo *ProductVariantRepository) Create(ctx context.Context, variant *ProductVariant) (*ProductVariant, error) {
+	err := repo.Database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(variant).Error; err != nil {
+			return err
+		}
+		return reapplyCampaigns(tx, variant)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return variant, nil
 }
 
-func (repo *ProductVariantRepository) GetBySKU(sku string) (*ProductVariant, error) {
+func (repo *ProductVariantRepository) GetBySKU(ctx context.Context, sku string) (*ProductVariant, error) {
 	var variant ProductVariant
-	result := repo.Database.DB.
+	result := repo.Database.DB.WithContext(ctx).
 		Where("sku = ?", sku).
 		First(&variant)
 
@@ -38,39 +52,73 @@ func (repo *ProductVariantRepository) GetBySKU(sku string) (*ProductVariant, err
 	return &variant, result.Error
 }
 
+// GetProductSellerID возвращает владельца продукта. Таблица читается напрямую:
+// пакет product сам зависит от productVariant.
+func (repo *ProductVariantRepository) GetProductSellerID(ctx context.Context, productID uint) (uint, error) {
+	var sellerIDs []uint
+	err := repo.Database.DB.WithContext(ctx).
+		Table("products").
+		Where("id = ? AND deleted_at IS NULL", productID).
+		Pluck("seller_id", &sellerIDs).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(sellerIDs) == 0 {
+		return 0, fmt.Errorf("product %d: %w", productID, gorm.ErrRecordNotFound)
+	}
+	return sellerIDs[0], nil
+}
+
+// GetProductCategoryID возвращает категорию продукта: по ней выбираются определения атрибутов.
+func (repo *ProductVariantRepository) GetProductCategoryID(ctx context.Context, productID uint) (uint, error) {
+	var categoryIDs []uint
+	err := repo.Database.DB.WithContext(ctx).
+		Table("products").
+		Where("id = ? AND deleted_at IS NULL", productID).
+		Pluck("category_id", &categoryIDs).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(categoryIDs) == 0 {
+		return 0, fmt.Errorf("product %d: %w", productID, gorm.ErrRecordNotFound)
+	}
+	return categoryIDs[0], nil
+}
+
 // проверять наличие продукта по ID
-func (repo *ProductVariantRepository) ExistsByProductID(id uint) (bool, error) {
+func (repo *ProductVariantRepository) ExistsByProductID(ctx context.Context, id uint) (bool, error) {
 	var variant ProductVariant
-	result := repo.Database.DB.Where("id = ?", id).First(&variant)
+	result := repo.Database.DB.WithContext(ctx).Where("id = ?", id).First(&variant)
 	return result.RowsAffected > 0, result.Error
 }
 
 // GetVariantsByProductID возвращает для продукта
-func (repo *ProductVariantRepository) GetVariantsByProductID(productID uint, includeInactive bool) ([]ProductVariant, error) {
+func (repo *ProductVariantRepository) GetVariantsByProductID(ctx context.Context, productID uint, includeInactive bool) ([]ProductVariant, error) {
 	var variants []ProductVariant
-	query := repo.Database.DB.Where("product_id = ?", productID)
+	query := repo.Database.DB.WithContext(ctx).Where("product_id = ?", productID)
 
 	if !includeInactive {
 		query = query.Where("is_active = true")
 	}
 
-	result := query.Find(&variants)
+	result := query.Preload("Attributes").Find(&variants)
 	return variants, result.Error
 }
 
 // GetActive возвращает только активные варианты
-func (repo *ProductVariantRepository) GetActive() ([]ProductVariant, error) {
+func (repo *ProductVariantRepository) GetActive(ctx context.Context) ([]ProductVariant, error) {
 	var variants []ProductVariant
-	result := repo.Database.DB.
+	result := repo.Database.DB.WithContext(ctx).
 		Where("is_active = true").
 		Find(&variants)
 	return variants, result.Error
 }
 
 // GetByID возвращает вариант по его ID
-func (repo *ProductVariantRepository) GetVariantByID(id uint) (*ProductVariant, error) {
+func (repo *ProductVariantRepository) GetVariantByID(ctx context.Context, id uint) (*ProductVariant, error) {
 	var variant ProductVariant
-	result := repo.Database.DB.
+	result := repo.Database.DB.WithContext(ctx).
+		Preload("Attributes").
 		Where("id = ?", id).
 		First(&variant)
 
@@ -80,24 +128,24 @@ func (repo *ProductVariantRepository) GetVariantByID(id uint) (*ProductVariant,
 	return &variant, result.Error
 }
 
-func (repo *ProductVariantRepository) GetVariantsByIDs(ids []uint) ([]ProductVariant, error) {
-    var variants []ProductVariant
-    if len(ids) == 0 {
-        return variants, nil
-    }
-    if err := repo.Database.DB.
-        Where("id IN ?", ids).
-        Find(&variants).
-        Error; err != nil {
-        return nil, err
-    }
-    return variants, nil
+func (repo *ProductVariantRepository) GetVariantsByIDs(ctx context.Context, ids []uint) ([]ProductVariant, error) {
+	var variants []ProductVariant
+	if len(ids) == 0 {
+		return variants, nil
+	}
+	if err := repo.Database.DB.WithContext(ctx).
+		Where("id IN ?", ids).
+		Find(&variants).
+		Error; err != nil {
+		return nil, err
+	}
+	return variants, nil
 }
 
 // GetByBarcode поиск по штрихкоду
-func (repo *ProductVariantRepository) GetByBarcode(barcode string) (*ProductVariant, error) {
+func (repo *ProductVariantRepository) GetByBarcode(ctx context.Context, barcode string) (*ProductVariant, error) {
 	var variant ProductVariant
-	result := repo.Database.DB.
+	result := repo.Database.DB.WithContext(ctx).
 		Where("barcode = ?", barcode).
 		First(&variant)
 
@@ -107,116 +155,199 @@ func (repo *ProductVariantRepository) GetByBarcode(barcode string) (*ProductVari
 	return &variant, result.Error
 }
 
-// Update обновляет вариант продукта
-func (repo *ProductVariantRepository) Update(variant *ProductVariant) (*ProductVariant, error) {
-	result := repo.Database.DB.Model(&ProductVariant{}).
-	Where("id = ?", variant.ID).
-	Updates(variant)
-
-	if result.Error != nil {
-		return nil, result.Error
+// Update обновляет вариант продукта. Остатки из variant не пишутся: reserved_stock меняет
+// только журнал броней, иначе запись прочитанного ранее значения затёрла бы параллельные брони.
+// stock, если задан, меняется тем же условным UPDATE, что и в UpdateStock, в той же транзакции:
+// при нехватке под бронь вариант не меняется вовсе.
+// Скидку акций и итоговую цену ведёт только Reprice: после записи они пересчитываются.
+// При replaceAttributes значения атрибутов заменяются на variant.Attributes.
+// События outbox строятся по записанному варианту и сохраняются в той же транзакции.
+func (repo *ProductVariantRepository) Update(ctx context.Context, variant *ProductVariant, replaceAttributes bool, stock *uint32, events ...variantEvent) (*ProductVariant, error) {
+	err := repo.Database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if stock != nil {
+			if err := setStock(tx, variant.ID, *stock); err != nil {
+				return err
+			}
+			variant.Stock = *stock
+		}
+		if err := tx.Model(&ProductVariant{}).
+			Where("id = ?", variant.ID).
+			Omit("stock", "reserved_stock", "campaign_discount", "effective_price", clause.Associations).
+			Updates(variant).Error; err != nil {
+			return err
+		}
+		if replaceAttributes {
+			if err := SaveAttributes(tx, variant); err != nil {
+				return err
+			}
+		}
+		if err := reapplyCampaigns(tx, variant); err != nil {
+			return err
+		}
+		built := make([]outbox.Event, 0, len(events))
+		for _, event := range events {
+			built = append(built, event(variant))
+		}
+		return outbox.Enqueue(tx, built...)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return variant, nil
 }
 
-// SoftDelete мягкое удаление
-func (repo *ProductVariantRepository) SoftDelete(id uint) error {
-	return repo.Database.DB.Delete(&ProductVariant{}, id).Error
+// SaveAttributes заменяет значения атрибутов варианта на variant.Attributes и пишет
+// пересобранные из них строки sizes, colors и dimensions в транзакции tx.
+func SaveAttributes(tx *gorm.DB, variant *ProductVariant) error {
+	// Updates по структуре пропускает пустые строки, а пересобранные строки могут стать пустыми
+	if err := tx.Model(&ProductVariant{}).
+		Where("id = ?", variant.ID).
+		Updates(map[string]interface{}{
+			"sizes":      variant.Sizes,
+			"colors":     variant.Colors,
+			"dimensions": variant.Dimensions,
+		}).Error; err != nil {
+		return err
+	}
+	return attribute.ReplaceValues(tx, variant.ID, variant.Attributes)
 }
 
-// GetByFilters поиск с фильтрами
-func (repo *ProductVariantRepository) GetByFilters(filters map[string]interface{}, limit, offset int) ([]ProductVariant, error) {
-	var variants []ProductVariant
-	query := repo.Database.DB.Model(&ProductVariant{})
-
-	for key, value := range filters {
-		switch key {
-		case "min_price":
-			query = query.Where("price >= ?", value)
-		case "max_price":
-			query = query.Where("price <= ?", value)
-		case "sizes":
-			query = query.Where("JSON_CONTAINS(sizes, ?)", value)
-		case "colors":
-			query = query.Where("JSON_CONTAINS(colors, ?)", value)
-		case "material":
-			query = query.Where("material = ?", value)
-		}
+// ReapplyAttributes перепроверяет сохранённые атрибуты варианта по определениям категории categoryID
+// в транзакции tx — при переносе продукта в другую категорию. Вариант без значений атрибутов
+// проверяется по строкам Sizes, Colors и Dimensions, как в ApplyAttributes.
+func ReapplyAttributes(tx *gorm.DB, categoryID uint, variant *ProductVariant) error {
+	var inputs []attribute.ValueInput
+	for _, v := range variant.Attributes {
+		inputs = append(inputs, v.Input())
 	}
-
-	result := query.Limit(limit).Offset(offset).Find(&variants)
-	return variants, result.Error
+	return applyAttributes(variant, inputs, func(inputs []attribute.ValueInput, legacy bool) ([]attribute.VariantValue, error) {
+		return attribute.Resolve(tx, categoryID, inputs, legacy)
+	})
 }
 
+// SoftDelete мягкое удаление
+func (repo *ProductVariantRepository) SoftDelete(ctx context.Context, id uint, events ...outbox.Event) error {
+	return repo.Database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&ProductVariant{}, id).Error; err != nil {
+			return err
+		}
+		return outbox.Enqueue(tx, events...)
+	})
+}
 
+// GetByFilters возвращает страницу вариантов по фильтрам и общее число подходящих вариантов.
+func (repo *ProductVariantRepository) GetByFilters(ctx context.Context, p *variantListParams) ([]ProductVariant, int64, error) {
+	var total int64
+	if err := applyVariantFilters(repo.Database.DB.WithContext(ctx).Model(&ProductVariant{}), p).
+		Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
 
-// GetAvailableStock возвращает доступное количество (Stock - ReservedStock)
-func (repo *ProductVariantRepository) GetAvailableStock(variantID uint) (uint32, error) {
-	var available struct {
-		Available uint32
+	var variants []ProductVariant
+	err := applyVariantFilters(repo.Database.DB.WithContext(ctx).Model(&ProductVariant{}), p).
+		Preload("Attributes").
+		Order(p.orderClause()).
+		Limit(p.pageSize).
+		Offset(p.offset()).
+		Find(&variants).Error
+	if err != nil {
+		return nil, 0, err
 	}
+	return variants, total, nil
+}
 
-	result := repo.Database.DB.Model(&ProductVariant{}).
-		Select("(stock - reserved_stock) as available").
-		Where("id = ?", variantID).
-		Scan(&available)
+// applyVariantFilters добавляет к запросу условия поиска вариантов.
+// Размеры и цвета хранятся списками через запятую и сравниваются поэлементно без учёта регистра.
+func applyVariantFilters(query *gorm.DB, p *variantListParams) *gorm.DB {
+	if p.productID != 0 {
+		query = query.Where("product_id = ?", p.productID)
+	}
+	if p.minPrice != nil {
+		query = query.Where("price >= ?", *p.minPrice)
+	}
+	if p.maxPrice != nil {
+		query = query.Where("price <= ?", *p.maxPrice)
+	}
+	if p.size != "" {
+		query = query.Where("? = ANY("+listValuesSQL("sizes")+")", p.size)
+	}
+	if p.color != "" {
+		query = query.Where("? = ANY("+listValuesSQL("colors")+")", p.color)
+	}
+	if p.inStock {
+		query = query.Where("stock > reserved_stock")
+	}
+	if p.isActive != nil {
+		query = query.Where("is_active = ?", *p.isActive)
+	}
+	if p.hasDiscount {
+		query = query.Where("discount > 0 OR campaign_discount > 0")
+	}
+	return query
+}
 
-	return available.Available, result.Error
+// listValuesSQL разбирает список через запятую в массив нормализованных значений.
+func listValuesSQL(column string) string {
+	return `regexp_split_to_array(lower(trim(` + column + `)), '\s*,\s*')`
 }
 
-// UpdateStock обновляет общий остаток на складе
-func (repo *ProductVariantRepository) UpdateStock(variantID uint, newStock uint32) error {
-	return repo.Database.DB.Model(&ProductVariant{}).
+// GetAvailableStock возвращает доступное количество (Stock - ReservedStock).
+// Для отсутствующего варианта возвращается gorm.ErrRecordNotFound, а не нулевой остаток.
+func (repo *ProductVariantRepository) GetAvailableStock(ctx context.Context, variantID uint) (uint32, error) {
+	var available []uint32
+	err := repo.Database.DB.WithContext(ctx).Model(&ProductVariant{}).
 		Where("id = ?", variantID).
-		Update("stock", newStock).Error
+		Pluck("stock - reserved_stock", &available).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(available) == 0 {
+		return 0, fmt.Errorf("product variant %d: %w", variantID, gorm.ErrRecordNotFound)
+	}
+	return available[0], nil
 }
 
-// ReserveStock резервирует указанное количество товара
-func (repo *ProductVariantRepository) ReserveStock(variantID uint, quantity uint32) error {
-	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
-		var variant ProductVariant
-		if err := tx.First(&variant, variantID).Error; err != nil {
-			return err
-		}
-
-		if variant.Stock < variant.ReservedStock+quantity {
-			return errors.New("not enough stock")
-		}
-
-		return tx.Model(&variant).
-			Update("reserved_stock", variant.ReservedStock+quantity).Error
-	})
+// UpdateStock обновляет общий остаток на складе. Условный UPDATE не даёт опустить
+// сток ниже текущей брони, даже если бронь появилась между чтением и записью.
+func (repo *ProductVariantRepository) UpdateStock(ctx context.Context, variantID uint, newStock uint32) error {
+	return setStock(repo.Database.DB.WithContext(ctx), variantID, newStock)
 }
 
-// ReleaseStock освобождает зарезервированный товар
-func (repo *ProductVariantRepository) ReleaseStock(variantID uint, quantity uint32) error {
-	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
-		var variant ProductVariant
-		if err := tx.First(&variant, variantID).Error; err != nil {
-			return err
-		}
-		if quantity > variant.ReservedStock {
-			return errors.New("release quantity exceeds reserved stock")
-		}
-		newReserved := variant.ReservedStock - quantity
-		
-		return tx.Model(&variant).
-			Update("reserved_stock", newReserved).Error
-	})
-}
+// BulkUpdateStock массовое обновление стока: всё или ничего.
+// Варианты обновляются по возрастанию ID, чтобы параллельные вызовы не взаимоблокировались.
+func (repo *ProductVariantRepository) BulkUpdateStock(ctx context.Context, variantStocks map[uint]uint32) error {
+	ids := make([]uint, 0, len(variantStocks))
+	for id := range variantStocks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
 
-// BulkUpdateStock массовое обновление стока
-func (repo *ProductVariantRepository) BulkUpdateStock(variantStocks map[uint]uint32) error {
-	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
-		for variantID, stock := range variantStocks {
-			if err := tx.Model(&ProductVariant{}).
-				Where("id = ?", variantID).
-				Update("stock", stock).Error; err != nil {
-				return err
+	return repo.Database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, id := range ids {
+			if err := setStock(tx, id, variantStocks[id]); err != nil {
+				return fmt.Errorf("variant %d: %w", id, err)
 			}
 		}
 		return nil
 	})
 }
 
-
+func setStock(db *gorm.DB, variantID uint, newStock uint32) error {
+	result := db.Model(&ProductVariant{}).
+		Where("id = ? AND reserved_stock <= ?", variantID, newStock).
+		Update("stock", newStock)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := db.Model(&ProductVariant{}).Where("id = ?", variantID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return ErrStockBelowReserved
+	}
+	return nil
+}
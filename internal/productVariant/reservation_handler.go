@@ -0,0 +1,165 @@
+package productVariant
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/product-service/internal/attribute"
+	"github.com/ShopOnGO/product-service/internal/auth"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ReservationHandlerDeps struct {
+	ReservationSvc *ReservationService
+	Auth           gin.HandlerFunc // проверка JWT
+}
+
+type ReservationHandler struct {
+	reservationSvc *ReservationService
+}
+
+func NewReservationHandler(router *gin.Engine, deps ReservationHandlerDeps) *ReservationHandler {
+	handler := &ReservationHandler{
+		reservationSvc: deps.ReservationSvc,
+	}
+
+	reservationGroup := router.Group("/product-service/reservations")
+	{
+		reservationGroup.GET("/:id", deps.Auth, auth.RequireRoles(auth.RoleService), handler.GetReservation)
+		reservationGroup.POST("/:id/confirm", deps.Auth, auth.RequireRoles(auth.RoleService), handler.ConfirmReservation)
+		reservationGroup.POST("/:id/cancel", deps.Auth, auth.RequireRoles(auth.RoleService), handler.CancelReservation)
+		reservationGroup.POST("/:id/commit", deps.Auth, auth.RequireRoles(auth.RoleService), handler.CommitReservation)
+	}
+
+	return handler
+}
+
+// GetReservation получает бронь по ID.
+// @Summary Получение брони
+// @Description Возвращает бронь с позициями и текущим статусом.
+// @Tags Брони
+// @Produce json
+// @Param id path string true "ID брони"
+// @Success 200 {object} Reservation
+// @Failure 404 {object} map[string]string "Бронь не найдена"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Только внутренние сервисы"
+// @Security ApiKeyAuth
+// @Router /reservations/{id} [get]
+func (h *ReservationHandler) GetReservation(c *gin.Context) {
+	reservation, err := h.reservationSvc.GetReservation(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeReservationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reservation)
+}
+
+// ConfirmReservation подтверждает бронь.
+// @Summary Подтверждение брони
+// @Description Переводит активную бронь в confirmed: товар остаётся удержанным, срок больше не истекает.
+// @Tags Брони
+// @Produce json
+// @Param id path string true "ID брони"
+// @Success 200 {object} Reservation
+// @Failure 404 {object} map[string]string "Бронь не найдена"
+// @Failure 409 {object} map[string]string "Бронь не активна или истекла"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Только внутренние сервисы"
+// @Security ApiKeyAuth
+// @Router /reservations/{id}/confirm [post]
+func (h *ReservationHandler) ConfirmReservation(c *gin.Context) {
+	reservation, err := h.reservationSvc.Confirm(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeReservationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reservation)
+}
+
+// CancelReservation отменяет бронь.
+// @Summary Отмена брони
+// @Description Отменяет активную или подтверждённую бронь и возвращает товар в свободный остаток.
+// @Tags Брони
+// @Produce json
+// @Param id path string true "ID брони"
+// @Success 200 {object} Reservation
+// @Failure 404 {object} map[string]string "Бронь не найдена"
+// @Failure 409 {object} map[string]string "Бронь уже снята"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Только внутренние сервисы"
+// @Security ApiKeyAuth
+// @Router /reservations/{id}/cancel [post]
+func (h *ReservationHandler) CancelReservation(c *gin.Context) {
+	reservation, err := h.reservationSvc.Cancel(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeReservationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reservation)
+}
+
+// CommitReservation списывает товар брони при отгрузке.
+// @Summary Списание брони
+// @Description Списывает товар активной или подтверждённой брони: stock и reserved_stock уменьшаются на количество позиций.
+// @Tags Брони
+// @Produce json
+// @Param id path string true "ID брони"
+// @Success 200 {object} Reservation
+// @Failure 404 {object} map[string]string "Бронь не найдена"
+// @Failure 409 {object} map[string]string "Бронь уже снята, списана или истекла"
+// @Failure 401 {object} map[string]string "Требуется авторизация"
+// @Failure 403 {object} map[string]string "Только внутренние сервисы"
+// @Security ApiKeyAuth
+// @Router /reservations/{id}/commit [post]
+func (h *ReservationHandler) CommitReservation(c *gin.Context) {
+	reservation, err := h.reservationSvc.Commit(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeReservationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reservation)
+}
+
+// writeReservationError переводит ошибки журнала броней в HTTP-статусы.
+func writeReservationError(c *gin.Context, err error) {
+	var shortage *InsufficientStockError
+	if errors.As(err, &shortage) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "shortfalls": shortage.Shortfalls})
+		return
+	}
+
+	switch {
+	case errors.Is(err, ErrInvalidReservation):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrReservationNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrNotEnoughStock), errors.Is(err, ErrInvalidReservationTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		logger.Errorf("Reservation error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// writeVariantError переводит ошибки изменения варианта и его остатков в HTTP-статусы.
+func writeVariantError(c *gin.Context, err error) {
+	if status, ok := attribute.ErrorStatus(err); ok {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrStockBelowReserved):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrUnauthenticated):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,47 @@
+package productVariant
+
+import "time"
+
+type ReservationStatus string
+
+const (
+	ReservationActive    ReservationStatus = "active"    // держит товар до ExpiresAt
+	ReservationConfirmed ReservationStatus = "confirmed" // заказ оформлен, бронь больше не истекает
+	ReservationCancelled ReservationStatus = "cancelled" // отменена, товар возвращён
+	ReservationExpired   ReservationStatus = "expired"   // истекла, товар возвращён чистильщиком
+	ReservationCommitted ReservationStatus = "committed" // товар отгружен: списан со стока вместе с бронью
+)
+
+// HoldsStock сообщает, учитываются ли позиции брони в ProductVariant.ReservedStock.
+func (s ReservationStatus) HoldsStock() bool {
+	return s == ReservationActive || s == ReservationConfirmed
+}
+
+// Reservation — запись журнала броней: кто (заказ) и сколько какого варианта удерживает.
+type Reservation struct {
+	ID        string            `gorm:"type:varchar(36);primaryKey" json:"id"`
+	OrderID   string            `gorm:"type:varchar(64);index;not null" json:"order_id"`
+	Status    ReservationStatus `gorm:"type:varchar(20);index;not null" json:"status"`
+	ExpiresAt time.Time         `gorm:"index;not null" json:"expires_at"`
+	Items     []ReservationItem `gorm:"foreignKey:ReservationID;constraint:OnDelete:CASCADE" json:"items"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// ReservationItem — позиция брони по одному варианту продукта.
+type ReservationItem struct {
+	ID               uint   `gorm:"primaryKey" json:"-"`
+	ReservationID    string `gorm:"type:varchar(36);index;not null" json:"-"`
+	ProductVariantID uint   `gorm:"index;not null" json:"product_variant_id"`
+	Quantity         uint32 `gorm:"not null" json:"quantity"`
+}
+
+// HasVariant сообщает, есть ли в брони позиция по варианту.
+func (r *Reservation) HasVariant(variantID uint) bool {
+	for _, item := range r.Items {
+		if item.ProductVariantID == variantID {
+			return true
+		}
+	}
+	return false
+}
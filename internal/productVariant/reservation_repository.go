@@ -0,0 +1,212 @@
+package productVariant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ShopOnGO/product-service/pkg/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidReservation           = errors.New("invalid reservation")
+	ErrNotEnoughStock               = errors.New("not enough stock")
+	ErrReservationNotFound          = errors.New("reservation not found")
+	ErrInvalidReservationTransition = errors.New("invalid reservation status transition")
+)
+
+const (
+	ShortfallNotFound     = "not_found"
+	ShortfallInactive     = "inactive"
+	ShortfallInsufficient = "insufficient_stock"
+)
+
+// StockShortfall — строка корзины, которую не удалось забронировать.
+type StockShortfall struct {
+	ProductVariantID uint   `json:"product_variant_id"`
+	Requested        uint32 `json:"requested"`
+	Available        uint32 `json:"available"`
+	Reason           string `json:"reason"`
+}
+
+// InsufficientStockError возвращается, если хотя бы одну позицию брони удержать нельзя.
+type InsufficientStockError struct {
+	Shortfalls []StockShortfall
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("%s: %d line(s) cannot be reserved", ErrNotEnoughStock, len(e.Shortfalls))
+}
+
+func (e *InsufficientStockError) Unwrap() error {
+	return ErrNotEnoughStock
+}
+
+type ReservationRepository struct {
+	Database *db.Db
+}
+
+func NewReservationRepository(database *db.Db) *ReservationRepository {
+	return &ReservationRepository{
+		Database: database,
+	}
+}
+
+// Create сохраняет бронь и увеличивает reserved_stock по всем её позициям в одной транзакции:
+// либо удерживается всё, либо ничего. Строки вариантов блокируются (SELECT ... FOR UPDATE)
+// по возрастанию ID, поэтому параллельные корзины с общими товарами не взаимоблокируются.
+// Удалённые варианты не находятся, а снятые с продажи не бронируются.
+func (repo *ReservationRepository) Create(ctx context.Context, reservation *Reservation) error {
+	// Позиции одного варианта складываются в uint64, чтобы сумма не переполнила uint32 и не прошла проверку остатка
+	totals := make(map[uint]uint64, len(reservation.Items))
+	for _, item := range reservation.Items {
+		totals[item.ProductVariantID] += uint64(item.Quantity)
+	}
+	quantities := make(map[uint]uint32, len(totals))
+	ids := make([]uint, 0, len(totals))
+	for id, total := range totals {
+		if total > math.MaxUint32 {
+			return fmt.Errorf("%w: total quantity of variant %d exceeds %d", ErrInvalidReservation, id, uint32(math.MaxUint32))
+		}
+		quantities[id] = uint32(total)
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return repo.Database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var variants []ProductVariant
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", ids).
+			Order("id").
+			Find(&variants).Error; err != nil {
+			return err
+		}
+
+		byID := make(map[uint]*ProductVariant, len(variants))
+		for i := range variants {
+			byID[variants[i].ID] = &variants[i]
+		}
+
+		var shortfalls []StockShortfall
+		for _, id := range ids {
+			requested := quantities[id]
+			variant, ok := byID[id]
+			if !ok {
+				shortfalls = append(shortfalls, StockShortfall{ProductVariantID: id, Requested: requested, Reason: ShortfallNotFound})
+				continue
+			}
+			if !variant.IsActive {
+				shortfalls = append(shortfalls, StockShortfall{ProductVariantID: id, Requested: requested, Reason: ShortfallInactive})
+				continue
+			}
+			if available := variant.AvailableStock(); available < requested {
+				shortfalls = append(shortfalls, StockShortfall{ProductVariantID: id, Requested: requested, Available: available, Reason: ShortfallInsufficient})
+			}
+		}
+		if len(shortfalls) > 0 {
+			return &InsufficientStockError{Shortfalls: shortfalls}
+		}
+
+		for _, id := range ids {
+			if err := tx.Model(&ProductVariant{}).
+				Where("id = ?", id).
+				Update("reserved_stock", gorm.Expr("reserved_stock + ?", quantities[id])).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(reservation).Error
+	})
+}
+
+// GetByID возвращает бронь вместе с позициями
+func (repo *ReservationRepository) GetByID(ctx context.Context, id string) (*Reservation, error) {
+	var reservation Reservation
+	err := repo.Database.DB.WithContext(ctx).
+		Preload("Items").
+		First(&reservation, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Transition переводит бронь в статус to, если сейчас она в одном из статусов from.
+// Если бронь перестаёт удерживать товар, reserved_stock уменьшается в той же транзакции,
+// а при переходе в committed на то же количество уменьшается и stock.
+func (repo *ReservationRepository) Transition(ctx context.Context, id string, from []ReservationStatus, to ReservationStatus) (*Reservation, error) {
+	var reservation Reservation
+	err := repo.Database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Items").First(&reservation, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrReservationNotFound
+			}
+			return err
+		}
+
+		// Условный UPDATE по статусу: из двух конкурирующих переходов срабатывает только один.
+		result := tx.Model(&Reservation{}).
+			Where("id = ? AND status IN ?", id, from).
+			Update("status", to)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidReservationTransition
+		}
+
+		if reservation.Status.HoldsStock() && !to.HoldsStock() {
+			release := releaseStock
+			if to == ReservationCommitted {
+				release = commitStock
+			}
+			for _, item := range reservation.Items {
+				if err := release(tx, item.ProductVariantID, item.Quantity); err != nil {
+					return err
+				}
+			}
+		}
+		reservation.Status = to
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// FindExpiredIDs возвращает ID активных броней, срок которых истёк к моменту now.
+func (repo *ReservationRepository) FindExpiredIDs(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	var ids []string
+	err := repo.Database.DB.WithContext(ctx).Model(&Reservation{}).
+		Where("status = ? AND expires_at <= ?", ReservationActive, now).
+		Order("expires_at").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// releaseStock возвращает удержанное количество; вариант мог быть удалён, пока бронь жила.
+func releaseStock(tx *gorm.DB, variantID uint, quantity uint32) error {
+	return tx.Unscoped().Model(&ProductVariant{}).
+		Where("id = ?", variantID).
+		Update("reserved_stock", gorm.Expr("GREATEST(reserved_stock - ?, 0)", quantity)).Error
+}
+
+// commitStock списывает отгруженное количество со стока и из брони одним UPDATE,
+// поэтому свободный остаток не меняется, а проверка reserved_stock <= stock не нарушается.
+func commitStock(tx *gorm.DB, variantID uint, quantity uint32) error {
+	return tx.Unscoped().Model(&ProductVariant{}).
+		Where("id = ?", variantID).
+		UpdateColumns(map[string]interface{}{
+			"stock":          gorm.Expr("GREATEST(stock - ?, 0)", quantity),
+			"reserved_stock": gorm.Expr("GREATEST(reserved_stock - ?, 0)", quantity),
+		}).Error
+}
@@ -0,0 +1,184 @@
+package productVariant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+	"github.com/ShopOnGO/product-service/internal/metrics"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultReservationTTL = 15 * time.Minute
+	MaxReservationTTL     = 24 * time.Hour
+	expireBatchSize       = 100
+)
+
+type ReservationService struct {
+	repo       *ReservationRepository
+	defaultTTL time.Duration
+}
+
+func NewReservationService(repo *ReservationRepository, defaultTTL time.Duration) *ReservationService {
+	if defaultTTL <= 0 {
+		defaultTTL = DefaultReservationTTL
+	}
+	return &ReservationService{
+		repo:       repo,
+		defaultTTL: defaultTTL,
+	}
+}
+
+// Reserve создаёт активную бронь под заказ. Если ttl не задан, используется TTL по умолчанию.
+func (s *ReservationService) Reserve(ctx context.Context, orderID string, items []ReservationItem, ttl time.Duration) (*Reservation, error) {
+	if orderID == "" {
+		return nil, fmt.Errorf("%w: order ID is required", ErrInvalidReservation)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: at least one item is required", ErrInvalidReservation)
+	}
+	for _, item := range items {
+		if item.ProductVariantID == 0 {
+			return nil, fmt.Errorf("%w: invalid product variant ID", ErrInvalidReservation)
+		}
+		if item.Quantity == 0 {
+			return nil, fmt.Errorf("%w: quantity must be greater than zero", ErrInvalidReservation)
+		}
+	}
+	if ttl <= 0 {
+		ttl = s.defaultTTL
+	}
+	if ttl > MaxReservationTTL {
+		ttl = MaxReservationTTL
+	}
+
+	reservation := &Reservation{
+		ID:        uuid.NewString(),
+		OrderID:   orderID,
+		Status:    ReservationActive,
+		ExpiresAt: time.Now().Add(ttl),
+		Items:     items,
+	}
+	if err := s.repo.Create(ctx, reservation); err != nil {
+		var shortage *InsufficientStockError
+		if errors.As(err, &shortage) {
+			metrics.Reservations.WithLabelValues("rejected").Inc()
+			for _, shortfall := range shortage.Shortfalls {
+				if shortfall.Reason == ShortfallInsufficient {
+					metrics.StockOuts.Inc()
+				}
+			}
+		}
+		return nil, err
+	}
+	metrics.Reservations.WithLabelValues("created").Inc()
+	return reservation, nil
+}
+
+// GetReservation возвращает бронь по ID.
+func (s *ReservationService) GetReservation(ctx context.Context, id string) (*Reservation, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// Confirm фиксирует бронь под оформленный заказ: товар остаётся удержанным, срок больше не истекает.
+func (s *ReservationService) Confirm(ctx context.Context, id string) (*Reservation, error) {
+	reservation, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.Status == ReservationActive && time.Now().After(reservation.ExpiresAt) {
+		return nil, ErrInvalidReservationTransition
+	}
+	return s.repo.Transition(ctx, id, []ReservationStatus{ReservationActive}, ReservationConfirmed)
+}
+
+// Cancel отменяет активную или подтверждённую бронь и возвращает товар в свободный остаток.
+func (s *ReservationService) Cancel(ctx context.Context, id string) (*Reservation, error) {
+	reservation, err := s.repo.Transition(ctx, id, []ReservationStatus{ReservationActive, ReservationConfirmed}, ReservationCancelled)
+	if err != nil {
+		return nil, err
+	}
+	metrics.ReservationReleases.WithLabelValues(string(ReservationCancelled)).Inc()
+	return reservation, nil
+}
+
+// Commit списывает товар активной или подтверждённой брони при отгрузке заказа:
+// stock и reserved_stock уменьшаются на количество позиций, бронь переходит в committed.
+func (s *ReservationService) Commit(ctx context.Context, id string) (*Reservation, error) {
+	reservation, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.Status == ReservationActive && time.Now().After(reservation.ExpiresAt) {
+		return nil, ErrInvalidReservationTransition
+	}
+	reservation, err = s.repo.Transition(ctx, id, []ReservationStatus{ReservationActive, ReservationConfirmed}, ReservationCommitted)
+	if err != nil {
+		return nil, err
+	}
+	metrics.Reservations.WithLabelValues(string(ReservationCommitted)).Inc()
+	return reservation, nil
+}
+
+// ExpireDue переводит просроченные активные брони в expired и возвращает их количество.
+func (s *ReservationService) ExpireDue(ctx context.Context, now time.Time) (int, error) {
+	ids, err := s.repo.FindExpiredIDs(ctx, now, expireBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		_, err := s.repo.Transition(ctx, id, []ReservationStatus{ReservationActive}, ReservationExpired)
+		if errors.Is(err, ErrInvalidReservationTransition) {
+			continue // бронь успели подтвердить или отменить
+		}
+		if err != nil {
+			return expired, err
+		}
+		metrics.ReservationReleases.WithLabelValues(string(ReservationExpired)).Inc()
+		expired++
+	}
+	return expired, nil
+}
+
+// ReservationSweeper периодически возвращает товар из просроченных броней.
+type ReservationSweeper struct {
+	svc      *ReservationService
+	interval time.Duration
+}
+
+func NewReservationSweeper(svc *ReservationService, interval time.Duration) *ReservationSweeper {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &ReservationSweeper{
+		svc:      svc,
+		interval: interval,
+	}
+}
+
+// Run работает до отмены контекста.
+func (sw *ReservationSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(sw.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Reservation sweeper stopped")
+			return
+		case <-ticker.C:
+			expired, err := sw.svc.ExpireDue(ctx, time.Now())
+			if err != nil {
+				logger.Errorf("Ошибка при снятии просроченных броней: %v", err)
+			}
+			if expired > 0 {
+				logger.Infof("Снято просроченных броней: %d", expired)
+			}
+		}
+	}
+}
@@ -1,69 +1,142 @@
 package productVariant
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
+	"github.com/ShopOnGO/product-service/internal/attribute"
+	"github.com/ShopOnGO/product-service/internal/auth"
 	// "github.com/ShopOnGO/product-service/pkg/interfaces"
 )
 
 type ProductVariantService struct {
-	repo *ProductVariantRepository
+	repo       *ProductVariantRepository
+	attributes *attribute.AttributeService
 	// productRepo *interfaces.ProductChecker
 }
 
-func NewProductVariantService(repo *ProductVariantRepository) *ProductVariantService {
+func NewProductVariantService(repo *ProductVariantRepository, attributes *attribute.AttributeService) *ProductVariantService {
 	return &ProductVariantService{
-		repo: repo,
+		repo:       repo,
+		attributes: attributes,
 		// productRepo: productRepo,
 	}
 }
 
-func (s *ProductVariantService) CreateProductVariant(variant *ProductVariant) (*ProductVariant, error) {
+// CreateProductVariant создаёт вариант. attrs — типизированные атрибуты; если они не заданы,
+// атрибуты разбираются из строк Sizes, Colors и Dimensions.
+func (s *ProductVariantService) CreateProductVariant(ctx context.Context, variant *ProductVariant, attrs []attribute.ValueInput) (*ProductVariant, error) {
+	if err := s.CheckProductOwnership(ctx, variant.ProductID); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateNewVariant(ctx, variant); err != nil {
+		return nil, err
+	}
+	categoryID, err := s.repo.GetProductCategoryID(ctx, variant.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ApplyAttributes(ctx, s.attributes, categoryID, variant, attrs); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, variant)
+}
+
+// ApplyAttributes проверяет атрибуты варианта по определениям категории и записывает их в variant.Attributes.
+// Явно заданные значения проверяются строго, а строки Sizes, Colors и Dimensions пересобираются из них.
+// При inputs == nil значения разбираются из этих строк; то, что не удалось сопоставить, остаётся только в строках.
+func ApplyAttributes(ctx context.Context, attributes *attribute.AttributeService, categoryID uint, variant *ProductVariant, inputs []attribute.ValueInput) error {
+	return applyAttributes(variant, inputs, func(inputs []attribute.ValueInput, legacy bool) ([]attribute.VariantValue, error) {
+		return attributes.Resolve(ctx, categoryID, inputs, legacy)
+	})
+}
+
+func applyAttributes(variant *ProductVariant, inputs []attribute.ValueInput, resolve func(inputs []attribute.ValueInput, legacy bool) ([]attribute.VariantValue, error)) error {
+	if inputs == nil {
+		values, err := resolve(attribute.LegacyInputs(variant.Sizes, variant.Colors, variant.Dimensions), true)
+		if err != nil {
+			return err
+		}
+		variant.Attributes = values
+		return nil
+	}
+
+	values, err := resolve(inputs, false)
+	if err != nil {
+		return err
+	}
+	variant.Attributes = values
+	variant.Sizes, variant.Colors, variant.Dimensions = attribute.LegacyStrings(values)
+	return nil
+}
+
+// ValidateNewVariant проверяет вариант перед созданием: артикул обязателен и уникален.
+func (s *ProductVariantService) ValidateNewVariant(ctx context.Context, variant *ProductVariant) error {
 	if variant.SKU == "" {
-		return nil, errors.New("SKU is required")
+		return errors.New("SKU is required")
 	}
 	// проверка что такой ID продукта есть
 	// exists, err := s.productRepo.ExistsByID(variant.ProductID)
 	// if !exists || err != nil {
 	// 	return nil, err
 	// }
-	
+
 	// Проверка уникальности SKU
-	existing, err := s.repo.GetBySKU(variant.SKU)
+	existing, err := s.repo.GetBySKU(ctx, variant.SKU)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if existing != nil {
-		return nil, fmt.Errorf("product variant with SKU %s already exists", variant.SKU)
+		return fmt.Errorf("product variant with SKU %s already exists", variant.SKU)
 	}
-	// Дополнительные проверки могут быть добавлены здесь (например, валидация размеров, цветов и пр.)
-	return s.repo.Create(variant)
+	return nil
 }
 
-func (s *ProductVariantService) GetProductVariantByID(id uint) (*ProductVariant, error) {
+func (s *ProductVariantService) GetProductVariantByID(ctx context.Context, id uint) (*ProductVariant, error) {
 	if id == 0 {
 		return nil, errors.New("invalid product variant ID")
 	}
-	return s.repo.GetVariantByID(id)
+	return s.repo.GetVariantByID(ctx, id)
 }
 
-func (s *ProductVariantService) GetVariantsByIDs(ids []uint) ([]ProductVariant, error) {
-    return s.repo.GetVariantsByIDs(ids)
+// ListVariants возвращает страницу вариантов с фильтрами, сортировкой и пагинацией.
+func (s *ProductVariantService) ListVariants(ctx context.Context, q VariantListQuery) (*VariantPage, error) {
+	params, err := newVariantListParams(q)
+	if err != nil {
+		return nil, err
+	}
+	variants, total, err := s.repo.GetByFilters(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return &VariantPage{
+		Items:    variants,
+		Total:    total,
+		Page:     params.page,
+		PageSize: params.pageSize,
+	}, nil
+}
+
+func (s *ProductVariantService) GetVariantsByIDs(ctx context.Context, ids []uint) ([]ProductVariant, error) {
+	return s.repo.GetVariantsByIDs(ctx, ids)
 }
 
-func (s *ProductVariantService) UpdateProductVariantByInput(variantID uint, input UpdateProductVariantPayload) (*ProductVariant, error) {
+func (s *ProductVariantService) UpdateProductVariantByInput(ctx context.Context, variantID uint, input UpdateProductVariantPayload) (*ProductVariant, error) {
 	if variantID == 0 {
 		return nil, errors.New("variant ID is required for update")
 	}
 	// Получаем существующий вариант
-	existing, err := s.repo.GetVariantByID(variantID)
+	existing, err := s.repo.GetVariantByID(ctx, variantID)
 	if err != nil {
 		return nil, fmt.Errorf("product variant with ID %d not found: %w", variantID, err)
 	}
 	if existing == nil {
 		return nil, fmt.Errorf("product variant with ID %d not found", variantID)
 	}
+	if err := s.CheckProductOwnership(ctx, existing.ProductID); err != nil {
+		return nil, err
+	}
 
 	// Обновляем поля, если входные данные заданы
 	if input.Price != nil {
@@ -72,18 +145,12 @@ func (s *ProductVariantService) UpdateProductVariantByInput(variantID uint, inpu
 	if input.Discount != nil {
 		existing.Discount = *input.Discount
 	}
-	if input.ReservedStock != nil {
-		existing.ReservedStock = *input.ReservedStock
-	}
 	if input.Sizes != nil {
 		existing.Sizes = *input.Sizes
 	}
 	if input.Colors != nil {
 		existing.Colors = *input.Colors
 	}
-	if input.Stock != nil {
-		existing.Stock = *input.Stock
-	}
 	if input.Barcode != nil {
 		existing.Barcode = *input.Barcode
 	}
@@ -100,71 +167,77 @@ func (s *ProductVariantService) UpdateProductVariantByInput(variantID uint, inpu
 		existing.Dimensions = *input.Dimensions
 	}
 
-	// Вызываем репозиторий для обновления
-	return s.repo.Update(existing)
+	// Атрибуты пересобираются, если заданы явно или изменились старые строки
+	replaceAttributes := input.Attributes != nil || input.Sizes != nil || input.Colors != nil || input.Dimensions != nil
+	if replaceAttributes {
+		var inputs []attribute.ValueInput
+		if input.Attributes != nil {
+			inputs = *input.Attributes
+			if inputs == nil {
+				inputs = []attribute.ValueInput{}
+			}
+		}
+		categoryID, err := s.repo.GetProductCategoryID(ctx, existing.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if err := ApplyAttributes(ctx, s.attributes, categoryID, existing, inputs); err != nil {
+			return nil, err
+		}
+	}
+
+	// Вызываем репозиторий для обновления; сток меняется условным UPDATE, чтобы не опустить
+	// его ниже брони, а variant-updated пишется в outbox — всё в одной транзакции
+	return s.repo.Update(ctx, existing, replaceAttributes, input.Stock, variantUpdatedEvent)
 }
 
 // DeleteProductVariant выполняет мягкое удаление варианта продукта.
-func (s *ProductVariantService) DeleteProductVariant(id uint) error {
+func (s *ProductVariantService) DeleteProductVariant(ctx context.Context, id uint) error {
 	if id == 0 {
 		return errors.New("invalid product variant ID")
 	}
-	return s.repo.SoftDelete(id)
-}
-
-// ReserveStock резервирует указанное количество товара, если доступно.
-func (s *ProductVariantService) ReserveStock(variantID uint, quantity uint32) error {
-	if quantity == 0 {
-		return errors.New("reserve quantity must be greater than zero")
-	}
-	return s.repo.ReserveStock(variantID, quantity)
-}
-
-// ReleaseStock освобождает указанное количество зарезервированного товара.
-// Добавлена базовая проверка, чтобы не освободить больше, чем зарезервировано.
-func (s *ProductVariantService) ReleaseStock(variantID uint, quantity uint32) error {
-	if quantity == 0 {
-		return errors.New("release quantity must be greater than zero")
-	}
-	// Дополнительная логика: проверка, чтобы не произошло переполнение (underflow)
-	variant, err := s.repo.GetVariantByID(variantID)
+	// Вариант читается до удаления: событию нужен ID продукта
+	existing, err := s.repo.GetVariantByID(ctx, id)
 	if err != nil {
 		return err
 	}
-	if quantity > variant.ReservedStock {
-		return errors.New("release quantity exceeds reserved stock")
+	if err := s.CheckProductOwnership(ctx, existing.ProductID); err != nil {
+		return err
 	}
-	return s.repo.ReleaseStock(variantID, quantity)
+	return s.repo.SoftDelete(ctx, id, variantDeletedEvent(existing))
 }
 
 // UpdateStock обновляет общее количество товара для варианта.
-func (s *ProductVariantService) UpdateStock(variantID uint, newStock uint32) error {
-	return s.repo.UpdateStock(variantID, newStock)
+func (s *ProductVariantService) UpdateStock(ctx context.Context, variantID uint, newStock uint32) error {
+	existing, err := s.repo.GetVariantByID(ctx, variantID)
+	if err != nil {
+		return err
+	}
+	if err := s.CheckProductOwnership(ctx, existing.ProductID); err != nil {
+		return err
+	}
+	return s.repo.UpdateStock(ctx, variantID, newStock)
 }
 
 // GetAvailableStock возвращает доступное количество товара (stock - reserved_stock).
-func (s *ProductVariantService) GetAvailableStock(variantID uint) (uint32, error) {
-	return s.repo.GetAvailableStock(variantID)
+func (s *ProductVariantService) GetAvailableStock(ctx context.Context, variantID uint) (uint32, error) {
+	return s.repo.GetAvailableStock(ctx, variantID)
 }
 
 // GetBySKU возвращает вариант продукта по артикулу.
-func (s *ProductVariantService) GetBySKU(sku string) (*ProductVariant, error) {
+func (s *ProductVariantService) GetBySKU(ctx context.Context, sku string) (*ProductVariant, error) {
 	if sku == "" {
 		return nil, errors.New("SKU is required")
 	}
-	return s.repo.GetBySKU(sku)
+	return s.repo.GetBySKU(ctx, sku)
 }
 
-
-// func (s *ProductService) CheckProductOwnership(productID, userID uint) error {
-//     owned, err := s.productRepo.IsProductOwnedByUser(productID, userID)
-//     if err != nil {
-//         return fmt.Errorf("ошибка при проверке прав владения продуктом: %w", err)
-//     }
-
-//     if !owned {
-//         return fmt.Errorf("forbidden: product %d does not belong to user %d", productID, userID)
-//     }
-
-//     return nil
-// }
+// CheckProductOwnership пропускает администратора и продавца, которому принадлежит продукт.
+func (s *ProductVariantService) CheckProductOwnership(ctx context.Context, productID uint) error {
+	sellerID, err := s.repo.GetProductSellerID(ctx, productID)
+	if err != nil {
+		return err
+	}
+	_, err = auth.RequireOwner(ctx, sellerID)
+	return err
+}
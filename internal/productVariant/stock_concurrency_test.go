@@ -0,0 +1,200 @@
+package productVariant_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ShopOnGO/product-service/internal/brand"
+	"github.com/ShopOnGO/product-service/internal/category"
+	"github.com/ShopOnGO/product-service/internal/product"
+	"github.com/ShopOnGO/product-service/internal/productVariant"
+	"github.com/ShopOnGO/product-service/migrations"
+	"github.com/ShopOnGO/product-service/pkg/db"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Тест работает с настоящим Postgres: блокировки строк и условные UPDATE не проверить на моках.
+// TEST_DSN должен указывать на отдельную одноразовую базу (в CI — сервис test_postgres), а не на базу
+// сервиса: схема создаётся миграциями сервиса со всеми их ограничениями. Без TEST_DSN тест пропускается.
+func openTestDB(t *testing.T) *db.Db {
+	t.Helper()
+	dsn := os.Getenv("TEST_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DSN is not set")
+	}
+	t.Setenv("DSN", dsn)
+	if err := migrations.RunMigrations(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	return &db.Db{DB: gormDB}
+}
+
+// createTestProduct создаёт продукт с категорией и брендом, к которому привязывается вариант,
+// и удаляет всё созданное по завершении теста.
+func createTestProduct(t *testing.T, database *db.Db) *product.Product {
+	t.Helper()
+	suffix := uuid.NewString()
+	c := &category.Category{Name: "concurrency-" + suffix}
+	b := &brand.Brand{Name: "concurrency-" + suffix}
+	if err := database.Create(c).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	if err := database.Create(b).Error; err != nil {
+		t.Fatalf("create brand: %v", err)
+	}
+	p := &product.Product{Name: "concurrency-" + suffix, CategoryID: c.ID, BrandID: b.ID}
+	if err := database.Omit(clause.Associations).Create(p).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	t.Cleanup(func() {
+		database.Unscoped().Delete(&product.Product{}, p.ID)
+		database.Unscoped().Delete(&brand.Brand{}, b.ID)
+		database.Unscoped().Delete(&category.Category{}, c.ID)
+	})
+	return p
+}
+
+// TestStockConcurrency параллельно бронирует, отменяет, списывает и переписывает сток
+// одного варианта и проверяет, что бронь никогда не превышает остаток, а reserved_stock
+// совпадает с суммой позиций удерживающих броней.
+func TestStockConcurrency(t *testing.T) {
+	database := openTestDB(t)
+	ctx := context.Background()
+
+	parent := createTestProduct(t, database)
+	variant := productVariant.ProductVariant{
+		ProductID: parent.ID,
+		SKU:       "concurrency-" + uuid.NewString(),
+		Price:     decimal.NewFromInt(100),
+		Stock:     10,
+	}
+	if err := database.Create(&variant).Error; err != nil {
+		t.Fatalf("create variant: %v", err)
+	}
+	// Регистрируется после продукта, поэтому выполняется раньше: сначала брони и вариант
+	t.Cleanup(func() {
+		database.Where("product_variant_id = ?", variant.ID).Delete(&productVariant.ReservationItem{})
+		database.Where("order_id LIKE ?", variant.SKU+"%").Delete(&productVariant.Reservation{})
+		database.Unscoped().Delete(&productVariant.ProductVariant{}, variant.ID)
+	})
+
+	reservations := productVariant.NewReservationRepository(database)
+	variants := productVariant.NewProductVariantRepository(database)
+
+	const (
+		workers = 16
+		rounds  = 25
+	)
+	errs := make(chan error, workers*rounds)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(int64(w)))
+			for i := 0; i < rounds; i++ {
+				if err := stockStep(ctx, rnd, reservations, variants, variant.ID, fmt.Sprintf("%s-%d-%d", variant.SKU, w, i)); err != nil {
+					errs <- err
+					return
+				}
+				if err := checkReserved(database, variant.ID); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if err := checkReserved(database, variant.ID); err != nil {
+		t.Fatal(err)
+	}
+	var current productVariant.ProductVariant
+	if err := database.First(&current, variant.ID).Error; err != nil {
+		t.Fatalf("read variant: %v", err)
+	}
+	var held int64
+	if err := database.Model(&productVariant.ReservationItem{}).
+		Joins("JOIN reservations r ON r.id = reservation_items.reservation_id").
+		Where("reservation_items.product_variant_id = ? AND r.status IN ?", variant.ID, []productVariant.ReservationStatus{productVariant.ReservationActive, productVariant.ReservationConfirmed}).
+		Select("COALESCE(SUM(reservation_items.quantity), 0)").
+		Scan(&held).Error; err != nil {
+		t.Fatalf("sum reservations: %v", err)
+	}
+	if int64(current.ReservedStock) != held {
+		t.Fatalf("reserved_stock = %d, holding reservations = %d", current.ReservedStock, held)
+	}
+}
+
+// stockStep выполняет одно случайное действие: меняет сток либо бронирует и затем
+// отменяет, подтверждает, списывает или оставляет бронь. Ожидаемые отказы — нехватка
+// товара, сток ниже брони — ошибкой не считаются.
+func stockStep(ctx context.Context, rnd *rand.Rand, reservations *productVariant.ReservationRepository, variants *productVariant.ProductVariantRepository, variantID uint, orderID string) error {
+	if rnd.Intn(3) == 0 {
+		err := variants.UpdateStock(ctx, variantID, uint32(rnd.Intn(15)))
+		if err != nil && !errors.Is(err, productVariant.ErrStockBelowReserved) {
+			return fmt.Errorf("update stock: %w", err)
+		}
+		return nil
+	}
+
+	reservation := &productVariant.Reservation{
+		ID:        uuid.NewString(),
+		OrderID:   orderID,
+		Status:    productVariant.ReservationActive,
+		ExpiresAt: time.Now().Add(time.Hour),
+		Items:     []productVariant.ReservationItem{{ProductVariantID: variantID, Quantity: uint32(1 + rnd.Intn(3))}},
+	}
+	if err := reservations.Create(ctx, reservation); err != nil {
+		if errors.Is(err, productVariant.ErrNotEnoughStock) {
+			return nil
+		}
+		return fmt.Errorf("reserve: %w", err)
+	}
+
+	var to productVariant.ReservationStatus
+	switch rnd.Intn(4) {
+	case 0:
+		to = productVariant.ReservationCancelled
+	case 1:
+		to = productVariant.ReservationConfirmed
+	case 2:
+		to = productVariant.ReservationCommitted
+	default:
+		return nil
+	}
+	from := []productVariant.ReservationStatus{productVariant.ReservationActive, productVariant.ReservationConfirmed}
+	if _, err := reservations.Transition(ctx, reservation.ID, from, to); err != nil {
+		return fmt.Errorf("%s reservation: %w", to, err)
+	}
+	return nil
+}
+
+func checkReserved(database *db.Db, variantID uint) error {
+	var v productVariant.ProductVariant
+	if err := database.Select("stock", "reserved_stock").First(&v, variantID).Error; err != nil {
+		return fmt.Errorf("read variant: %w", err)
+	}
+	if v.ReservedStock > v.Stock {
+		return fmt.Errorf("reserved_stock %d exceeds stock %d", v.ReservedStock, v.Stock)
+	}
+	return nil
+}
@@ -3,14 +3,20 @@ package migrations
 import (
 	"fmt"
 	"os"
-	
+
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
 	"github.com/ShopOnGO/product-service/configs"
+	"github.com/ShopOnGO/product-service/internal/attribute"
+	"github.com/ShopOnGO/product-service/internal/audit"
 	"github.com/ShopOnGO/product-service/internal/brand"
+	"github.com/ShopOnGO/product-service/internal/campaign"
 	"github.com/ShopOnGO/product-service/internal/category"
+	"github.com/ShopOnGO/product-service/internal/deadletter"
+	"github.com/ShopOnGO/product-service/internal/inbox"
+	"github.com/ShopOnGO/product-service/internal/outbox"
 	"github.com/ShopOnGO/product-service/internal/product"
 	"github.com/ShopOnGO/product-service/internal/productVariant"
 )
@@ -42,16 +48,98 @@ func RunMigrations() error {
 		return fmt.Errorf("failed to connect to DB: %w", err)
 	}
 
+	clamped, err := clampReservedStock(db)
+	if err != nil {
+		return fmt.Errorf("failed to clamp reserved stock: %w", err)
+	}
+	if clamped > 0 {
+		logger.Warnf("Reserved stock clamped to stock for %d variants", clamped)
+	}
+
 	// Авто-миграции
 	if err := db.AutoMigrate(
 		&product.Product{},
 		&productVariant.ProductVariant{},
+		&productVariant.Reservation{},
+		&productVariant.ReservationItem{},
+		&outbox.Message{},
+		&inbox.ProcessedEvent{},
+		&deadletter.DeadLetter{},
+		&audit.Entry{},
 		&category.Category{},
 		&brand.Brand{},
+		&attribute.Definition{},
+		&attribute.VariantValue{},
+		&campaign.Campaign{},
+		&campaign.CampaignTarget{},
 	); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	if err := restrictCatalogDeletes(db); err != nil {
+		return fmt.Errorf("failed to update foreign keys: %w", err)
+	}
+
+	if err := installProductSearch(db); err != nil {
+		return fmt.Errorf("failed to install product search: %w", err)
+	}
+
+	if err := backfillEffectivePrices(db); err != nil {
+		return fmt.Errorf("failed to backfill effective prices: %w", err)
+	}
+
+	migrated, err := attribute.MigrateLegacy(db)
+	if err != nil {
+		return fmt.Errorf("failed to migrate variant attributes: %w", err)
+	}
+	logger.Infof("Variant attributes migrated: %d", migrated)
+
 	logger.Info("✅ Migrations completed")
 	return nil
 }
+
+// clampReservedStock приводит старые варианты к проверке reserved_stock <= stock до AutoMigrate:
+// он создаёт её ограничением, и одна нарушающая строка сорвала бы всю миграцию.
+func clampReservedStock(db *gorm.DB) (int64, error) {
+	if !db.Migrator().HasTable(&productVariant.ProductVariant{}) {
+		return 0, nil
+	}
+	result := db.Exec(`UPDATE product_variants SET reserved_stock = stock WHERE reserved_stock > stock`)
+	return result.RowsAffected, result.Error
+}
+
+// restrictCatalogDeletes пересоздаёт внешние ключи на бренды и категории с ON DELETE RESTRICT.
+// AutoMigrate не меняет существующие ограничения, а в старых базах они созданы с CASCADE,
+// из-за чего удаление бренда или категории удаляло все их продукты.
+func restrictCatalogDeletes(db *gorm.DB) error {
+	constraints := []struct {
+		model interface{}
+		field string
+	}{
+		{&product.Product{}, "Category"},
+		{&product.Product{}, "Brand"},
+		{&category.Category{}, "SubCategories"}, // для самоссылки GORM строит ключ по has-many связи
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, c := range constraints {
+			if migrator.HasConstraint(c.model, c.field) {
+				if err := migrator.DropConstraint(c.model, c.field); err != nil {
+					return err
+				}
+			}
+			if err := migrator.CreateConstraint(c.model, c.field); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// backfillEffectivePrices заполняет итоговую цену вариантов, созданных до появления акций:
+// колонка добавляется с нулём, а скидок акций у таких вариантов ещё нет.
+func backfillEffectivePrices(db *gorm.DB) error {
+	return db.Exec(`UPDATE product_variants SET effective_price = GREATEST(price - discount, 0)
+		WHERE effective_price = 0 AND campaign_discount = 0 AND price > discount`).Error
+}
@@ -0,0 +1,78 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// productSearchSQL создаёт полнотекстовый индекс продуктов. search_vector не описан в модели:
+// его заполняет триггер на каждой записи продукта, а при переименовании бренда или категории
+// триггеры на их таблицах пересчитывают вектор связанных продуктов.
+// Словари russian и english дают стемминг обоих языков, веса: название A, бренд и категория B,
+// материал C, описание D. Индекс pg_trgm по названию нужен для нечёткого поиска с опечатками.
+const productSearchSQL = `
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+CREATE OR REPLACE FUNCTION products_search_vector_update() RETURNS trigger AS $$
+DECLARE
+	brand_name    text;
+	category_name text;
+	refs          text;
+BEGIN
+	SELECT name INTO brand_name FROM brands WHERE id = NEW.brand_id;
+	SELECT name INTO category_name FROM categories WHERE id = NEW.category_id;
+	refs := coalesce(brand_name, '') || ' ' || coalesce(category_name, '');
+
+	NEW.search_vector :=
+		setweight(to_tsvector('russian', coalesce(NEW.name, '')), 'A') ||
+		setweight(to_tsvector('english', coalesce(NEW.name, '')), 'A') ||
+		setweight(to_tsvector('russian', refs), 'B') ||
+		setweight(to_tsvector('english', refs), 'B') ||
+		setweight(to_tsvector('russian', coalesce(NEW.material, '')), 'C') ||
+		setweight(to_tsvector('english', coalesce(NEW.material, '')), 'C') ||
+		setweight(to_tsvector('russian', coalesce(NEW.description, '')), 'D') ||
+		setweight(to_tsvector('english', coalesce(NEW.description, '')), 'D');
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_search_vector_trigger ON products;
+CREATE TRIGGER products_search_vector_trigger
+	BEFORE INSERT OR UPDATE OF name, description, material, brand_id, category_id ON products
+	FOR EACH ROW EXECUTE FUNCTION products_search_vector_update();
+
+CREATE OR REPLACE FUNCTION products_search_vector_refresh_brand() RETURNS trigger AS $$
+BEGIN
+	UPDATE products SET brand_id = brand_id WHERE brand_id = NEW.id;
+	RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS brands_search_vector_trigger ON brands;
+CREATE TRIGGER brands_search_vector_trigger
+	AFTER UPDATE OF name ON brands
+	FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name)
+	EXECUTE FUNCTION products_search_vector_refresh_brand();
+
+CREATE OR REPLACE FUNCTION products_search_vector_refresh_category() RETURNS trigger AS $$
+BEGIN
+	UPDATE products SET category_id = category_id WHERE category_id = NEW.id;
+	RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS categories_search_vector_trigger ON categories;
+CREATE TRIGGER categories_search_vector_trigger
+	AFTER UPDATE OF name ON categories
+	FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name)
+	EXECUTE FUNCTION products_search_vector_refresh_category();
+
+UPDATE products SET name = name WHERE search_vector IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING gin (search_vector);
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING gin (name gin_trgm_ops);
+`
+
+// installProductSearch создаёт индекс и триггеры поиска; повторный запуск безопасен.
+func installProductSearch(db *gorm.DB) error {
+	return db.Exec(productSearchSQL).Error
+}
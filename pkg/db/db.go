@@ -10,10 +10,20 @@ type Db struct {
 	*gorm.DB
 }
 
-func NewDB(conf *configs.Config) *Db {
-	db, err := gorm.Open(postgres.Open(conf.Db.Dsn), &gorm.Config{})
+// NewDB открывает пул соединений без обязательного пинга: недоступная при старте БД
+// не роняет сервис, а отражается в /readyz. Ошибка возвращается только для неверной конфигурации.
+func NewDB(conf *configs.Config) (*Db, error) {
+	db, err := gorm.Open(postgres.Open(conf.Db.Dsn), &gorm.Config{
+		DisableAutomaticPing: true,
+	})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	return &Db{db}
+	if err := db.Use(tracingPlugin{}); err != nil {
+		return nil, err
+	}
+	if err := db.Use(timeoutPlugin{timeout: conf.Db.QueryTimeout}); err != nil {
+		return nil, err
+	}
+	return &Db{db}, nil
 }
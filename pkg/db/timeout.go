@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	timeoutCancelKey  = "timeout:cancel"
+	timeoutContextKey = "timeout:parent"
+)
+
+// timeoutPlugin ограничивает SQL-запрос сроком timeout, если у контекста вызова нет своего
+// deadline (фоновые задачи, Kafka). Запросы HTTP и gRPC отменяются вместе с вызовом через
+// WithContext, поэтому их срок задаёт клиент или интерцептор gRPC.
+// Row/Rows (и Scan поверх них) читают строки уже после колбэка, и отменить контекст было бы
+// негде, поэтому плагин их не ограничивает: запросы Raw(...).Scan выполняются через RawScan.
+type timeoutPlugin struct {
+	timeout time.Duration
+}
+
+func (timeoutPlugin) Name() string {
+	return "query-timeout"
+}
+
+func (p timeoutPlugin) Initialize(db *gorm.DB) error {
+	if p.timeout <= 0 {
+		return nil
+	}
+
+	cb := db.Callback()
+	hooks := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, h := range hooks {
+		if err := h.before("timeout:before_"+h.operation, p.before); err != nil {
+			return err
+		}
+		if err := h.after("timeout:after_"+h.operation, after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p timeoutPlugin) before(tx *gorm.DB) {
+	parent := tx.Statement.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	if _, ok := parent.Deadline(); ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(parent, p.timeout)
+	tx.Statement.Context = ctx
+	tx.InstanceSet(timeoutContextKey, parent)
+	tx.InstanceSet(timeoutCancelKey, cancel)
+}
+
+// after возвращает исходный контекст и освобождает таймер: Statement переиспользуется цепочкой
+// (Count, затем Find), и следующий запрос должен получить собственный срок, а не уже отменённый.
+func after(tx *gorm.DB) {
+	value, _ := tx.InstanceGet(timeoutContextKey)
+	parent, ok := value.(context.Context)
+	if !ok {
+		return
+	}
+	tx.Statement.Context = parent
+	tx.InstanceSet(timeoutContextKey, nil)
+	if cancel, ok := tx.InstanceGet(timeoutCancelKey); ok {
+		cancel.(context.CancelFunc)()
+	}
+}
+
+// RawScan выполняет tx.Raw(sql, values...).Scan(dest) со сроком по умолчанию плагина, если у
+// контекста tx нет своего deadline. Срок отменяется после чтения всех строк, а не в колбэке.
+// tx может быть транзакцией: запрос выполняется в ней же.
+func RawScan(tx *gorm.DB, dest interface{}, sql string, values ...interface{}) error {
+	ctx := tx.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if plugin, ok := tx.Config.Plugins[timeoutPlugin{}.Name()].(timeoutPlugin); ok && plugin.timeout > 0 {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, plugin.timeout)
+			defer cancel()
+		}
+	}
+	return tx.WithContext(ctx).Raw(sql, values...).Scan(dest).Error
+}
@@ -0,0 +1,66 @@
+package interfaces
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrHasProducts     = errors.New("products are attached, choose strategy reassign, deactivate or force")
+	ErrUnknownStrategy = errors.New("unknown delete strategy")
+	// ErrReassignAttributes — атрибуты вариантов не проходят проверку категории, на которую переносятся продукты.
+	ErrReassignAttributes = errors.New("variant attributes do not fit the target category")
+)
+
+// ProductRef — колонка products, по которой продукт ссылается на бренд или категорию.
+type ProductRef string
+
+const (
+	RefBrand    ProductRef = "brand_id"
+	RefCategory ProductRef = "category_id"
+)
+
+// DeleteStrategy — что делать с продуктами удаляемого бренда или категории.
+type DeleteStrategy string
+
+const (
+	DeleteBlock      DeleteStrategy = ""           // отказать, если продукты есть
+	DeleteReassign   DeleteStrategy = "reassign"   // перенести продукты на другой бренд или категорию
+	DeleteDeactivate DeleteStrategy = "deactivate" // снять продукты с продажи, ссылка остаётся
+	DeleteForce      DeleteStrategy = "force"      // удалить продукты вместе с вариантами
+)
+
+// ProductDetacher работает с продуктами удаляемого бренда или категории в транзакции вызывающего,
+// чтобы удаление и изменения продуктов фиксировались вместе. Методы возвращают число затронутых продуктов.
+type ProductDetacher interface {
+	CountByRef(tx *gorm.DB, ref ProductRef, id uint) (int64, error)
+	Reassign(tx *gorm.DB, ref ProductRef, id, targetID uint) (int64, error)
+	Deactivate(tx *gorm.DB, ref ProductRef, id uint) (int64, error)
+	DeleteByRef(tx *gorm.DB, ref ProductRef, id uint) (int64, error)
+}
+
+// Detach применяет стратегию к продуктам, ссылающимся на id, и возвращает число затронутых продуктов.
+// При DeleteBlock продукты не меняются, а их наличие возвращается как ErrHasProducts.
+func Detach(tx *gorm.DB, products ProductDetacher, ref ProductRef, id uint, strategy DeleteStrategy, targetID uint) (int64, error) {
+	switch strategy {
+	case DeleteBlock:
+		count, err := products.CountByRef(tx, ref, id)
+		if err != nil {
+			return 0, err
+		}
+		if count > 0 {
+			return count, fmt.Errorf("%w: %d products", ErrHasProducts, count)
+		}
+		return 0, nil
+	case DeleteReassign:
+		return products.Reassign(tx, ref, id, targetID)
+	case DeleteDeactivate:
+		return products.Deactivate(tx, ref, id)
+	case DeleteForce:
+		return products.DeleteByRef(tx, ref, id)
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrUnknownStrategy, strategy)
+	}
+}
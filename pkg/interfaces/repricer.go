@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// VariantRepricer пересчитывает скидку акций и итоговую цену вариантов в транзакции вызывающего,
+// чтобы изменение акции и новые цены фиксировались вместе. Возвращает число изменившихся вариантов.
+type VariantRepricer interface {
+	Reprice(tx *gorm.DB, variantIDs []uint, now time.Time) (int, error)
+}
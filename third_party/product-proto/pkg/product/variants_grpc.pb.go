@@ -0,0 +1,405 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: variants.proto
+
+package product
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ProductVariantService_CheckProductVariantExists_FullMethodName = "/product_variant.ProductVariantService/CheckProductVariantExists"
+	ProductVariantService_GetProductVariants_FullMethodName        = "/product_variant.ProductVariantService/GetProductVariants"
+	ProductVariantService_ReserveStockBatch_FullMethodName         = "/product_variant.ProductVariantService/ReserveStockBatch"
+	ProductVariantService_ListProductVariants_FullMethodName       = "/product_variant.ProductVariantService/ListProductVariants"
+	ProductVariantService_ReserveStock_FullMethodName              = "/product_variant.ProductVariantService/ReserveStock"
+	ProductVariantService_ReleaseStock_FullMethodName              = "/product_variant.ProductVariantService/ReleaseStock"
+	ProductVariantService_CommitStock_FullMethodName               = "/product_variant.ProductVariantService/CommitStock"
+	ProductVariantService_GetAvailableStock_FullMethodName         = "/product_variant.ProductVariantService/GetAvailableStock"
+)
+
+// ProductVariantServiceClient is the client API for ProductVariantService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ProductVariantServiceClient interface {
+	CheckProductVariantExists(ctx context.Context, in *CheckProductVariantRequest, opts ...grpc.CallOption) (*CheckProductVariantResponse, error)
+	GetProductVariants(ctx context.Context, in *GetProductVariantsRequest, opts ...grpc.CallOption) (*GetProductVariantsResponse, error)
+	// Атомарно бронирует все позиции корзины: либо все, либо ни одной.
+	// При нехватке возвращает FAILED_PRECONDITION с PreconditionFailure по каждой строке.
+	ReserveStockBatch(ctx context.Context, in *ReserveStockBatchRequest, opts ...grpc.CallOption) (*ReserveStockBatchResponse, error)
+	// Поиск вариантов по фильтрам с сортировкой и постраничной пагинацией.
+	// Неверные параметры возвращают INVALID_ARGUMENT.
+	ListProductVariants(ctx context.Context, in *ListProductVariantsRequest, opts ...grpc.CallOption) (*ListProductVariantsResponse, error)
+	// Управление остатками для сервиса заказов. Ошибки: NOT_FOUND — нет варианта или брони,
+	// FAILED_PRECONDITION — не хватает товара или бронь уже снята, INVALID_ARGUMENT — неверный запрос.
+	// Бронирует один вариант под заказ.
+	ReserveStock(ctx context.Context, in *ReserveStockRequest, opts ...grpc.CallOption) (*ReserveStockResponse, error)
+	// Снимает бронь и возвращает товар в свободный остаток.
+	ReleaseStock(ctx context.Context, in *ReleaseStockRequest, opts ...grpc.CallOption) (*ReservationStatusResponse, error)
+	// Списывает товар брони при отгрузке: уменьшает stock и reserved_stock.
+	CommitStock(ctx context.Context, in *CommitStockRequest, opts ...grpc.CallOption) (*ReservationStatusResponse, error)
+	GetAvailableStock(ctx context.Context, in *GetAvailableStockRequest, opts ...grpc.CallOption) (*GetAvailableStockResponse, error)
+}
+
+type productVariantServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewProductVariantServiceClient(cc grpc.ClientConnInterface) ProductVariantServiceClient {
+	return &productVariantServiceClient{cc}
+}
+
+func (c *productVariantServiceClient) CheckProductVariantExists(ctx context.Context, in *CheckProductVariantRequest, opts ...grpc.CallOption) (*CheckProductVariantResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckProductVariantResponse)
+	err := c.cc.Invoke(ctx, ProductVariantService_CheckProductVariantExists_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productVariantServiceClient) GetProductVariants(ctx context.Context, in *GetProductVariantsRequest, opts ...grpc.CallOption) (*GetProductVariantsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetProductVariantsResponse)
+	err := c.cc.Invoke(ctx, ProductVariantService_GetProductVariants_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productVariantServiceClient) ReserveStockBatch(ctx context.Context, in *ReserveStockBatchRequest, opts ...grpc.CallOption) (*ReserveStockBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReserveStockBatchResponse)
+	err := c.cc.Invoke(ctx, ProductVariantService_ReserveStockBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productVariantServiceClient) ListProductVariants(ctx context.Context, in *ListProductVariantsRequest, opts ...grpc.CallOption) (*ListProductVariantsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListProductVariantsResponse)
+	err := c.cc.Invoke(ctx, ProductVariantService_ListProductVariants_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productVariantServiceClient) ReserveStock(ctx context.Context, in *ReserveStockRequest, opts ...grpc.CallOption) (*ReserveStockResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReserveStockResponse)
+	err := c.cc.Invoke(ctx, ProductVariantService_ReserveStock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productVariantServiceClient) ReleaseStock(ctx context.Context, in *ReleaseStockRequest, opts ...grpc.CallOption) (*ReservationStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReservationStatusResponse)
+	err := c.cc.Invoke(ctx, ProductVariantService_ReleaseStock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productVariantServiceClient) CommitStock(ctx context.Context, in *CommitStockRequest, opts ...grpc.CallOption) (*ReservationStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReservationStatusResponse)
+	err := c.cc.Invoke(ctx, ProductVariantService_CommitStock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productVariantServiceClient) GetAvailableStock(ctx context.Context, in *GetAvailableStockRequest, opts ...grpc.CallOption) (*GetAvailableStockResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetAvailableStockResponse)
+	err := c.cc.Invoke(ctx, ProductVariantService_GetAvailableStock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ProductVariantServiceServer is the server API for ProductVariantService service.
+// All implementations must embed UnimplementedProductVariantServiceServer
+// for forward compatibility.
+type ProductVariantServiceServer interface {
+	CheckProductVariantExists(context.Context, *CheckProductVariantRequest) (*CheckProductVariantResponse, error)
+	GetProductVariants(context.Context, *GetProductVariantsRequest) (*GetProductVariantsResponse, error)
+	// Атомарно бронирует все позиции корзины: либо все, либо ни одной.
+	// При нехватке возвращает FAILED_PRECONDITION с PreconditionFailure по каждой строке.
+	ReserveStockBatch(context.Context, *ReserveStockBatchRequest) (*ReserveStockBatchResponse, error)
+	// Поиск вариантов по фильтрам с сортировкой и постраничной пагинацией.
+	// Неверные параметры возвращают INVALID_ARGUMENT.
+	ListProductVariants(context.Context, *ListProductVariantsRequest) (*ListProductVariantsResponse, error)
+	// Управление остатками для сервиса заказов. Ошибки: NOT_FOUND — нет варианта или брони,
+	// FAILED_PRECONDITION — не хватает товара или бронь уже снята, INVALID_ARGUMENT — неверный запрос.
+	// Бронирует один вариант под заказ.
+	ReserveStock(context.Context, *ReserveStockRequest) (*ReserveStockResponse, error)
+	// Снимает бронь и возвращает товар в свободный остаток.
+	ReleaseStock(context.Context, *ReleaseStockRequest) (*ReservationStatusResponse, error)
+	// Списывает товар брони при отгрузке: уменьшает stock и reserved_stock.
+	CommitStock(context.Context, *CommitStockRequest) (*ReservationStatusResponse, error)
+	GetAvailableStock(context.Context, *GetAvailableStockRequest) (*GetAvailableStockResponse, error)
+	mustEmbedUnimplementedProductVariantServiceServer()
+}
+
+// UnimplementedProductVariantServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedProductVariantServiceServer struct{}
+
+func (UnimplementedProductVariantServiceServer) CheckProductVariantExists(context.Context, *CheckProductVariantRequest) (*CheckProductVariantResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckProductVariantExists not implemented")
+}
+func (UnimplementedProductVariantServiceServer) GetProductVariants(context.Context, *GetProductVariantsRequest) (*GetProductVariantsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProductVariants not implemented")
+}
+func (UnimplementedProductVariantServiceServer) ReserveStockBatch(context.Context, *ReserveStockBatchRequest) (*ReserveStockBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReserveStockBatch not implemented")
+}
+func (UnimplementedProductVariantServiceServer) ListProductVariants(context.Context, *ListProductVariantsRequest) (*ListProductVariantsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProductVariants not implemented")
+}
+func (UnimplementedProductVariantServiceServer) ReserveStock(context.Context, *ReserveStockRequest) (*ReserveStockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReserveStock not implemented")
+}
+func (UnimplementedProductVariantServiceServer) ReleaseStock(context.Context, *ReleaseStockRequest) (*ReservationStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseStock not implemented")
+}
+func (UnimplementedProductVariantServiceServer) CommitStock(context.Context, *CommitStockRequest) (*ReservationStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CommitStock not implemented")
+}
+func (UnimplementedProductVariantServiceServer) GetAvailableStock(context.Context, *GetAvailableStockRequest) (*GetAvailableStockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAvailableStock not implemented")
+}
+func (UnimplementedProductVariantServiceServer) mustEmbedUnimplementedProductVariantServiceServer() {}
+func (UnimplementedProductVariantServiceServer) testEmbeddedByValue()                               {}
+
+// UnsafeProductVariantServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ProductVariantServiceServer will
+// result in compilation errors.
+type UnsafeProductVariantServiceServer interface {
+	mustEmbedUnimplementedProductVariantServiceServer()
+}
+
+func RegisterProductVariantServiceServer(s grpc.ServiceRegistrar, srv ProductVariantServiceServer) {
+	// If the following call pancis, it indicates UnimplementedProductVariantServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ProductVariantService_ServiceDesc, srv)
+}
+
+func _ProductVariantService_CheckProductVariantExists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckProductVariantRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductVariantServiceServer).CheckProductVariantExists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductVariantService_CheckProductVariantExists_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductVariantServiceServer).CheckProductVariantExists(ctx, req.(*CheckProductVariantRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductVariantService_GetProductVariants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProductVariantsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductVariantServiceServer).GetProductVariants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductVariantService_GetProductVariants_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductVariantServiceServer).GetProductVariants(ctx, req.(*GetProductVariantsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductVariantService_ReserveStockBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReserveStockBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductVariantServiceServer).ReserveStockBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductVariantService_ReserveStockBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductVariantServiceServer).ReserveStockBatch(ctx, req.(*ReserveStockBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductVariantService_ListProductVariants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProductVariantsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductVariantServiceServer).ListProductVariants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductVariantService_ListProductVariants_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductVariantServiceServer).ListProductVariants(ctx, req.(*ListProductVariantsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductVariantService_ReserveStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReserveStockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductVariantServiceServer).ReserveStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductVariantService_ReserveStock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductVariantServiceServer).ReserveStock(ctx, req.(*ReserveStockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductVariantService_ReleaseStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseStockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductVariantServiceServer).ReleaseStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductVariantService_ReleaseStock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductVariantServiceServer).ReleaseStock(ctx, req.(*ReleaseStockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductVariantService_CommitStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommitStockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductVariantServiceServer).CommitStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductVariantService_CommitStock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductVariantServiceServer).CommitStock(ctx, req.(*CommitStockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductVariantService_GetAvailableStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAvailableStockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductVariantServiceServer).GetAvailableStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductVariantService_GetAvailableStock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductVariantServiceServer).GetAvailableStock(ctx, req.(*GetAvailableStockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ProductVariantService_ServiceDesc is the grpc.ServiceDesc for ProductVariantService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ProductVariantService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "product_variant.ProductVariantService",
+	HandlerType: (*ProductVariantServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CheckProductVariantExists",
+			Handler:    _ProductVariantService_CheckProductVariantExists_Handler,
+		},
+		{
+			MethodName: "GetProductVariants",
+			Handler:    _ProductVariantService_GetProductVariants_Handler,
+		},
+		{
+			MethodName: "ReserveStockBatch",
+			Handler:    _ProductVariantService_ReserveStockBatch_Handler,
+		},
+		{
+			MethodName: "ListProductVariants",
+			Handler:    _ProductVariantService_ListProductVariants_Handler,
+		},
+		{
+			MethodName: "ReserveStock",
+			Handler:    _ProductVariantService_ReserveStock_Handler,
+		},
+		{
+			MethodName: "ReleaseStock",
+			Handler:    _ProductVariantService_ReleaseStock_Handler,
+		},
+		{
+			MethodName: "CommitStock",
+			Handler:    _ProductVariantService_CommitStock_Handler,
+		},
+		{
+			MethodName: "GetAvailableStock",
+			Handler:    _ProductVariantService_GetAvailableStock_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "variants.proto",
+}